@@ -0,0 +1,198 @@
+package htmx
+
+import (
+	"encoding/json"
+	"html/template"
+	"strings"
+	"time"
+)
+
+type (
+	// Attributes builds hx-* attributes for html generated on the server, values are escaped for use
+	// inside double quoted attributes.
+	Attributes struct {
+		attrs        []attribute
+		swapDuration time.Duration
+		settleDelay  time.Duration
+	}
+
+	attribute struct {
+		name  string
+		value string
+	}
+)
+
+// HxVals returns the hx-vals attribute carrying the JSON encoding of v, escaped so quotes inside string values
+// keep the attribute well-formed. It returns the encoding error instead of a broken attribute.
+// https://htmx.org/attributes/hx-vals/
+func HxVals(v any) (template.HTMLAttr, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return template.HTMLAttr(`hx-vals='` + template.HTMLEscapeString(string(payload)) + `'`), nil
+}
+
+// Attrs returns a new, empty, attribute set using DefaultSwapDuration and DefaultSettleDelay, see SwapWithObject.
+func Attrs() *Attributes {
+	return &Attributes{
+		swapDuration: DefaultSwapDuration,
+		settleDelay:  DefaultSettleDelay,
+	}
+}
+
+// Attrs returns a new, empty, attribute set using the swap and settle defaults of the htmx instance.
+func (h *HTMX) Attrs() *Attributes {
+	return &Attributes{
+		swapDuration: h.swapDuration,
+		settleDelay:  h.settleDelay,
+	}
+}
+
+// Attr sets the attribute with the given name, setting an attribute twice replaces its value.
+func (a *Attributes) Attr(name, value string) *Attributes {
+	for i := range a.attrs {
+		if a.attrs[i].name == name {
+			a.attrs[i].value = value
+			return a
+		}
+	}
+
+	a.attrs = append(a.attrs, attribute{name: name, value: value})
+	return a
+}
+
+// Get issues a GET request to the url, see https://htmx.org/attributes/hx-get/
+func (a *Attributes) Get(url string) *Attributes {
+	return a.Attr("hx-get", url)
+}
+
+// Post issues a POST request to the url, see https://htmx.org/attributes/hx-post/
+func (a *Attributes) Post(url string) *Attributes {
+	return a.Attr("hx-post", url)
+}
+
+// Put issues a PUT request to the url, see https://htmx.org/attributes/hx-put/
+func (a *Attributes) Put(url string) *Attributes {
+	return a.Attr("hx-put", url)
+}
+
+// Patch issues a PATCH request to the url, see https://htmx.org/attributes/hx-patch/
+func (a *Attributes) Patch(url string) *Attributes {
+	return a.Attr("hx-patch", url)
+}
+
+// Delete issues a DELETE request to the url, see https://htmx.org/attributes/hx-delete/
+func (a *Attributes) Delete(url string) *Attributes {
+	return a.Attr("hx-delete", url)
+}
+
+// Target sets the element the response is swapped into, see https://htmx.org/attributes/hx-target/
+func (a *Attributes) Target(selector string) *Attributes {
+	return a.Attr("hx-target", selector)
+}
+
+// Select sets the part of the response that is swapped in, see https://htmx.org/attributes/hx-select/
+func (a *Attributes) Select(selector string) *Attributes {
+	return a.Attr("hx-select", selector)
+}
+
+// Swap sets how the response is swapped in, see https://htmx.org/attributes/hx-swap/
+func (a *Attributes) Swap(swap string) *Attributes {
+	return a.Attr("hx-swap", swap)
+}
+
+// SwapStyle sets the style the response is swapped in with, see https://htmx.org/attributes/hx-swap/
+func (a *Attributes) SwapStyle(style SwapStyle) *Attributes {
+	return a.Swap(style.String())
+}
+
+// SwapWithObject sets how the response is swapped in from a Swap, see https://htmx.org/attributes/hx-swap/
+// Like Handler.ReSwapWithObject the default timings are added unless the Swap sets them or WithoutDefaults was called.
+func (a *Attributes) SwapWithObject(s *Swap) *Attributes {
+	return a.Swap(s.renderWithDefaults(a.swapDuration, a.settleDelay))
+}
+
+// Trigger sets the events triggering the request, see https://htmx.org/attributes/hx-trigger/
+func (a *Attributes) Trigger(trigger string) *Attributes {
+	return a.Attr("hx-trigger", trigger)
+}
+
+// Vals adds the JSON encoding of v to the request parameters, see https://htmx.org/attributes/hx-vals/
+// Like Trigger.String a value that cannot be encoded is left out.
+func (a *Attributes) Vals(v any) *Attributes {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return a
+	}
+
+	return a.Attr("hx-vals", string(payload))
+}
+
+// Include adds the values of other elements to the request, see https://htmx.org/attributes/hx-include/
+func (a *Attributes) Include(selector string) *Attributes {
+	return a.Attr("hx-include", selector)
+}
+
+// Confirm asks the user to confirm before sending the request, see https://htmx.org/attributes/hx-confirm/
+func (a *Attributes) Confirm(message string) *Attributes {
+	return a.Attr("hx-confirm", message)
+}
+
+// Indicator sets the element shown while the request is in flight, see https://htmx.org/attributes/hx-indicator/
+func (a *Attributes) Indicator(selector string) *Attributes {
+	return a.Attr("hx-indicator", selector)
+}
+
+// PushURL sets whether the request url is pushed into the history, see https://htmx.org/attributes/hx-push-url/
+func (a *Attributes) PushURL(push bool) *Attributes {
+	return a.Attr("hx-push-url", HxBoolToStr(push))
+}
+
+// String returns the attributes in the order they were first set, separated by a space.
+func (a *Attributes) String() string {
+	var b strings.Builder
+
+	for i, attr := range a.attrs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(attr.name + `="` + template.HTMLEscapeString(attr.value) + `"`)
+	}
+
+	return b.String()
+}
+
+// HTMLAttr returns the attributes as template.HTMLAttr, which html/template inserts without escaping them again.
+func (a *Attributes) HTMLAttr() template.HTMLAttr {
+	return template.HTMLAttr(a.String())
+}
+
+// SSEConnect returns the attributes connecting the element to the server sent events stream at url through the
+// htmx sse extension, swapping the data of swapEvent events into it, no sse-swap is set when swapEvent is empty.
+// It returns an empty attribute when url or swapEvent contain a line break.
+// https://htmx.org/extensions/sse/
+func SSEConnect(url string, swapEvent string) template.HTMLAttr {
+	if _, err := sanitizeHeaderValue(url + swapEvent); err != nil {
+		return ""
+	}
+
+	a := Attrs().Attr("hx-ext", "sse").Attr("sse-connect", url)
+	if swapEvent != "" {
+		a.Attr("sse-swap", swapEvent)
+	}
+
+	return a.HTMLAttr()
+}
+
+// WSConnect returns the attributes connecting the element to the websocket at url through the htmx ws extension.
+// It returns an empty attribute when url contains a line break.
+// https://htmx.org/extensions/ws/
+func WSConnect(url string) template.HTMLAttr {
+	if _, err := sanitizeHeaderValue(url); err != nil {
+		return ""
+	}
+
+	return Attrs().Attr("hx-ext", "ws").Attr("ws-connect", url).HTMLAttr()
+}
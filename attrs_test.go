@@ -0,0 +1,54 @@
+package htmx
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestAttrs(t *testing.T) {
+	attrs := Attrs().Get("/pokemon?type=fire&page=2").Target("#list").Swap("outerHTML").Trigger("click").PushURL(true)
+
+	equal(t, `hx-get="/pokemon?type=fire&amp;page=2" hx-target="#list" hx-swap="outerHTML" hx-trigger="click" hx-push-url="true"`, attrs.String())
+
+	attrs = Attrs().Post("/save").Vals(map[string]any{"name": `Farfetch'd "duck"`}).Target("#a").Target("#b")
+
+	equal(t, `hx-post="/save" hx-vals="{&#34;name&#34;:&#34;Farfetch&#39;d \&#34;duck\&#34;&#34;}" hx-target="#b"`, attrs.String())
+}
+
+func TestAttrsTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("button").Parse(`<button {{.}}>delete</button>`))
+
+	var b strings.Builder
+	err := tmpl.Execute(&b, Attrs().Delete("/items/1").Confirm("Sure?").Include("#form").Indicator("#spinner").HTMLAttr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equal(t, `<button hx-delete="/items/1" hx-confirm="Sure?" hx-include="#form" hx-indicator="#spinner">delete</button>`, b.String())
+}
+
+func TestHxVals(t *testing.T) {
+	attr, err := HxVals(map[string]string{"quote": `it's "fine"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equal(t, `hx-vals='{&#34;quote&#34;:&#34;it&#39;s \&#34;fine\&#34;&#34;}'`, string(attr))
+
+	if _, err := HxVals(make(chan int)); err == nil {
+		t.Error("expected an error for a value that cannot be encoded")
+	}
+}
+
+func TestSSEConnect(t *testing.T) {
+	equal(t, `hx-ext="sse" sse-connect="/events?room=1&amp;user=&#34;ash&#34;" sse-swap="message"`, string(SSEConnect(`/events?room=1&user="ash"`, "message")))
+	equal(t, `hx-ext="sse" sse-connect="/events"`, string(SSEConnect("/events", "")))
+	equal(t, "", string(SSEConnect("/events\r\nX-Injected: 1", "message")))
+	equal(t, "", string(SSEConnect("/events", "message\n")))
+}
+
+func TestWSConnect(t *testing.T) {
+	equal(t, `hx-ext="ws" ws-connect="/chat?room=1&amp;page=2"`, string(WSConnect("/chat?room=1&page=2")))
+	equal(t, "", string(WSConnect("/chat\n")))
+}
@@ -0,0 +1,144 @@
+package htmx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type (
+	// limitedBody records whether the handler has read past the limit of the http.MaxBytesReader it wraps.
+	limitedBody struct {
+		io.ReadCloser
+		exceeded bool
+	}
+
+	// limitedWriter drops what the handler writes once the body limit is exceeded, the rejection is written instead.
+	limitedWriter struct {
+		http.ResponseWriter
+		body  *limitedBody
+		wrote bool
+	}
+)
+
+// MaxBodyBytes limits request bodies to n bytes. Requests announcing a larger Content-Length are rejected before
+// next runs. Otherwise the body is wrapped with http.MaxBytesReader and reading past the limit fails in next,
+// typically in ParseForm or a decoder: what next writes from then on is dropped and the request is rejected once
+// next returns, unless next had already written a response.
+// htmx requests are rejected by onTooLarge, which gets a Handler to retarget an error message, and get
+// 413 Request Entity Too Large unless onTooLarge writes another status. Other requests, or a nil onTooLarge,
+// get a plain 413.
+// Behind the Middleware the Handler of the request is limited too, so writes through FromContext are dropped, and
+// the htmx headers it staged are discarded before the rejection.
+func (h *HTMX) MaxBodyBytes(n int64, onTooLarge func(*Handler)) func(http.Handler) http.Handler {
+	reject := func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := FromContext(r.Context())
+		if ok {
+			handler.discardStaged()
+			handler.status = http.StatusOK
+			w = handler
+		}
+
+		if onTooLarge == nil || !h.IsHxRequest(r) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if !ok {
+			handler = h.newHandler(w, r)
+			defer handler.Release()
+		}
+
+		handler.status = http.StatusRequestEntityTooLarge
+		onTooLarge(handler)
+		handler.WriteHeader(handler.status)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				reject(w, r)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, n)}
+			lw := &limitedWriter{ResponseWriter: w, body: body}
+
+			handler, ok := FromContext(r.Context())
+			if ok {
+				handler.bodyLimit = body
+			}
+
+			r.Body = body
+			next.ServeHTTP(lw, r)
+
+			wrote := lw.wrote
+			if ok {
+				handler.bodyLimit = nil
+				wrote = wrote || handler.isCommitted()
+			}
+
+			if !body.exceeded {
+				return
+			}
+
+			if wrote {
+				h.log.Warn("htmx: request body too large after the response was written", "path", r.URL.Path)
+				return
+			}
+
+			clearResponseHeaders(w.Header())
+			reject(w, r)
+		})
+	}
+}
+
+// clearResponseHeaders removes the htmx and Content-Type headers a handler set before its response was dropped,
+// so they do not leak into the rejection.
+func clearResponseHeaders(header http.Header) {
+	for k := range header {
+		if k == "Content-Type" || strings.HasPrefix(k, "Hx-") {
+			delete(header, k)
+		}
+	}
+}
+
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+
+	return n, err
+}
+
+// isExceeded returns true once the handler read past the limit, false for a nil body, a handler without limit.
+func (b *limitedBody) isExceeded() bool {
+	return b != nil && b.exceeded
+}
+
+func (w *limitedWriter) WriteHeader(code int) {
+	if w.body.exceeded {
+		return
+	}
+
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.body.exceeded {
+		return len(p), nil
+	}
+
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying response writer, see http.ResponseController.
+func (w *limitedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,130 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	form := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_, _ = w.Write([]byte("saved " + r.PostForm.Get("name")))
+	})
+
+	limit := New().MaxBodyBytes(16, func(h *Handler) {
+		h.ReTarget("#form-error")
+		h.JustWriteString("The upload is too large")
+	})(form)
+
+	tests := []struct {
+		name    string
+		body    string
+		hx      bool
+		chunked bool
+		code    int
+		target  string
+		out     string
+	}{
+		{"small", "name=ash", true, false, http.StatusOK, "", "saved ash"},
+		{"content length", "name=" + strings.Repeat("a", 32), true, false, http.StatusRequestEntityTooLarge, "#form-error", "The upload is too large"},
+		{"read past limit", "name=" + strings.Repeat("a", 32), true, true, http.StatusRequestEntityTooLarge, "#form-error", "The upload is too large"},
+		{"plain request", "name=" + strings.Repeat("a", 32), false, true, http.StatusRequestEntityTooLarge, "", "Request Entity Too Large\n"},
+	}
+
+	dropped := New().MaxBodyBytes(16, func(h *Handler) {
+		h.JustWriteString("The upload is too large")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+
+		w.Header().Set(HXTrigger.String(), "saved")
+		w.Header().Set(HXRetarget.String(), "#list")
+		w.Header().Set("Content-Type", "application/json")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}))
+
+	t.Run("stale headers", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name="+strings.Repeat("a", 32)))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("HX-Request", "true")
+		r.ContentLength = -1
+
+		rec := httptest.NewRecorder()
+		dropped.ServeHTTP(rec, r)
+
+		equalInt(t, http.StatusRequestEntityTooLarge, rec.Code)
+		equal(t, "", rec.Header().Get(HXTrigger.String()))
+		equal(t, "", rec.Header().Get(HXRetarget.String()))
+		equal(t, "", rec.Header().Get("Content-Type"))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.hx {
+				r.Header.Set("HX-Request", "true")
+			}
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+
+			rec := httptest.NewRecorder()
+			limit.ServeHTTP(rec, r)
+
+			equalInt(t, tt.code, rec.Code)
+			equal(t, tt.target, rec.Header().Get(HXRetarget.String()))
+			equal(t, tt.out, rec.Body.String())
+		})
+	}
+}
+
+func TestMaxBodyBytesBehindMiddleware(t *testing.T) {
+	hx := New()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, _ := FromContext(r.Context())
+		h.Trigger("saved")
+		h.ReTarget("#list")
+
+		_ = r.ParseForm()
+		h.JustWriteString("saved ok")
+	})
+
+	handler := hx.Middleware(hx.MaxBodyBytes(4, func(h *Handler) {
+		h.ReTarget("#err")
+		h.JustWriteString("too large")
+	})(next))
+
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{"content length", false},
+		{"read past limit", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name="+strings.Repeat("a", 32)))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.Header.Set("HX-Request", "true")
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			equalInt(t, http.StatusRequestEntityTooLarge, rec.Code)
+			equal(t, "#err", rec.Header().Get(HXRetarget.String()))
+			equal(t, "", rec.Header().Get(HXTrigger.String()))
+			equal(t, "too large", rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package htmx
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// bufferWriter is the response writer of a buffered Handler, the status is only recorded.
+type bufferWriter struct {
+	*bytes.Buffer
+	header http.Header
+	status int
+}
+
+// Buffered returns a clone of the handler writing into the returned buffer instead of the response, e.g. to
+// render a reusable fragment function and assemble its output into an out of band response.
+// The clone shares the request and the settings of the handler, its htmx response headers are its own and only
+// reach the response through MergeHeaders. The fragment filters only run once the buffer is written to the
+// handler. The clone is never committed to the client, flushing, hijacking and the streaming helpers are not
+// supported. It is not pooled, it needs no Release.
+func (h *Handler) Buffered() (*Handler, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+
+	clone := *h
+	clone.w = &bufferWriter{Buffer: buf, header: http.Header{}}
+	clone.response = &HxResponseHeader{headers: http.Header{}}
+	clone.triggers = nil
+	clone.reads = nil
+	clone.filters = nil
+	clone.onCommit = nil
+	clone.state = &commitState{}
+	clone.status = http.StatusOK
+	clone.oob = nil
+	clone.pool = nil
+	clone.compression = false
+	clone.gz = nil
+	clone.observer = noopObserver{}
+
+	return &clone, buf
+}
+
+// MergeHeaders copies the htmx response headers staged on child, a handler returned by Buffered, to the handler.
+// Triggered events are merged with the ones of the handler, see mergeTrigger, other headers replace the value
+// of the handler. The Vary values of child, and the request headers it read, see VaryFromRequest, are added as well.
+func (h *Handler) MergeHeaders(child *Handler) {
+	h.markRead(child.reads...)
+
+	merged := make(map[string]bool, len(child.triggers))
+	for k, t := range child.triggers {
+		merged[http.CanonicalHeaderKey(k.String())] = true
+
+		if val, ok := h.encodeTrigger(k, t); ok {
+			h.setHeader(k, val)
+		}
+	}
+
+	for k, v := range child.response.headers {
+		if !merged[k] {
+			h.response.headers[k] = append([]string(nil), v...)
+		}
+	}
+
+	for _, v := range child.w.Header().Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			h.Vary(strings.TrimSpace(field))
+		}
+	}
+}
+
+func (w *bufferWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
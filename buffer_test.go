@@ -0,0 +1,37 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuffered(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://example.com/")
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, r)
+	handler.TriggerWithObject(NewTrigger().AddEvent("page"))
+
+	child, buf := handler.Buffered()
+	child.ReTarget("#cart")
+	child.TriggerWithObject(NewTrigger().AddEvent("cartChanged"))
+	child.WriteHeader(http.StatusTeapot)
+	_, _ = child.WriteString(`<div id="cart">3</div>`)
+	_ = child.CurrentURL()
+
+	equal(t, `<div id="cart">3</div>`, buf.String())
+	equal(t, "", handler.response.Get(HXRetarget))
+	equal(t, "page", handler.response.Get(HXTrigger))
+	equalBool(t, false, handler.isCommitted())
+	equalInt(t, 0, len(rec.Header()))
+
+	handler.MergeHeaders(child)
+	_, _ = handler.WriteString("page")
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "page", rec.Body.String())
+	equal(t, "#cart", rec.Header().Get(HXRetarget.String()))
+	equal(t, "page, cartChanged", rec.Header().Get(HXTrigger.String()))
+	equal(t, HxRequestHeaderCurrentURL.String(), rec.Header().Get("Vary"))
+}
@@ -0,0 +1,123 @@
+package htmx
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"sync"
+)
+
+// DefaultFragmentCacheSize is the number of fragments the cache of a htmx instance keeps, see
+// Handler.CachedFragment and WithFragmentCache.
+var DefaultFragmentCacheSize = 256
+
+type (
+	// FragmentCache keeps the rendered fragments of Handler.CachedFragment, one version per key.
+	// Implementations must be safe for concurrent use. NewLRUFragmentCache returns an in-memory cache.
+	FragmentCache interface {
+		// Get returns the version and the rendered fragment stored for key.
+		Get(key string) (version string, fragment []byte, ok bool)
+		// Set stores the rendered fragment for key, replacing any other version.
+		Set(key, version string, fragment []byte)
+	}
+
+	// LRUFragmentCache is an in-memory FragmentCache dropping the least recently used fragment once full.
+	LRUFragmentCache struct {
+		mu      sync.Mutex
+		size    int
+		order   *list.List
+		entries map[string]*list.Element
+	}
+
+	lruFragment struct {
+		key      string
+		version  string
+		fragment []byte
+	}
+)
+
+// NewLRUFragmentCache returns an empty LRUFragmentCache keeping up to size fragments, at least one.
+func NewLRUFragmentCache(size int) *LRUFragmentCache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &LRUFragmentCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the version and the rendered fragment stored for key, which becomes the most recently used.
+func (c *LRUFragmentCache) Get(key string) (string, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return "", nil, false
+	}
+
+	c.order.MoveToFront(e)
+	f := e.Value.(*lruFragment)
+	return f.version, f.fragment, true
+}
+
+// Set stores the rendered fragment for key, dropping the least recently used fragment when the cache is full.
+func (c *LRUFragmentCache) Set(key, version string, fragment []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		f := e.Value.(*lruFragment)
+		f.version, f.fragment = version, fragment
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruFragment{key: key, version: version, fragment: fragment})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruFragment).key)
+	}
+}
+
+// Len returns the number of fragments in the cache.
+func (c *LRUFragmentCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// CachedFragment writes the fragment cached for key when it was rendered for version, otherwise it renders it
+// with render, caches and writes it. Changing version, e.g. the update time of the data, invalidates the fragment.
+// When render fails nothing is cached or written and the error is returned. Concurrent misses of a key both
+// render, the last one is kept. The cache of the htmx instance is used, see WithFragmentCache, requests other
+// than htmx requests always render when the instance was configured with WithFragmentCacheHtmxOnly.
+func (h *Handler) CachedFragment(key, version string, render func(io.Writer) error) error {
+	cache := h.fragmentCache
+	if cache == nil || (h.fragmentCacheHxOnly && !h.request.HxRequest) {
+		return render(h)
+	}
+
+	if cached, fragment, ok := cache.Get(key); ok && cached == version {
+		h.log.Debug("htmx: serving cached fragment", "key", key)
+		_, err := h.Write(fragment)
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+
+	fragment := buf.Bytes()
+	cache.Set(key, version, fragment)
+
+	_, err := h.Write(fragment)
+	return err
+}
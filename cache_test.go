@@ -0,0 +1,105 @@
+package htmx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCachedFragment(t *testing.T) {
+	h := New()
+	renders := 0
+	render := func(w io.Writer) error {
+		renders++
+		_, err := io.WriteString(w, "<ul>"+strconv.Itoa(renders)+"</ul>")
+		return err
+	}
+
+	serve := func(version string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HxRequestHeaderRequest.String(), "true")
+		rec := httptest.NewRecorder()
+
+		if err := h.NewHandler(rec, req).CachedFragment("list", version, render); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Body.String()
+	}
+
+	equal(t, "<ul>1</ul>", serve("v1"))
+	equal(t, "<ul>1</ul>", serve("v1"))
+	equalInt(t, 1, renders)
+
+	equal(t, "<ul>2</ul>", serve("v2"))
+	equal(t, "<ul>2</ul>", serve("v2"))
+	equalInt(t, 2, renders)
+}
+
+func TestCachedFragmentError(t *testing.T) {
+	cache := NewLRUFragmentCache(4)
+	rec := httptest.NewRecorder()
+	handler := New(WithFragmentCache(cache)).NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	failed := errors.New("database down")
+	err := handler.CachedFragment("list", "v1", func(w io.Writer) error {
+		_, _ = io.WriteString(w, "<ul>")
+		return failed
+	})
+
+	equalBool(t, true, errors.Is(err, failed))
+	equal(t, "", rec.Body.String())
+	equalInt(t, 0, cache.Len())
+}
+
+func TestCachedFragmentHtmxOnly(t *testing.T) {
+	cache := NewLRUFragmentCache(4)
+	rec := httptest.NewRecorder()
+	handler := New(WithFragmentCache(cache), WithFragmentCacheHtmxOnly()).NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	_ = handler.CachedFragment("list", "v1", func(w io.Writer) error {
+		_, err := io.WriteString(w, "<ul></ul>")
+		return err
+	})
+
+	equal(t, "<ul></ul>", rec.Body.String())
+	equalInt(t, 0, cache.Len())
+}
+
+func TestLRUFragmentCache(t *testing.T) {
+	cache := NewLRUFragmentCache(2)
+	cache.Set("a", "1", []byte("a"))
+	cache.Set("b", "1", []byte("b"))
+	cache.Get("a")
+	cache.Set("c", "1", []byte("c"))
+
+	_, _, ok := cache.Get("b")
+	equalBool(t, false, ok)
+
+	version, fragment, ok := cache.Get("a")
+	equalBool(t, true, ok)
+	equal(t, "1", version)
+	equal(t, "a", string(fragment))
+	equalInt(t, 2, cache.Len())
+}
+
+func TestLRUFragmentCacheConcurrent(t *testing.T) {
+	cache := NewLRUFragmentCache(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := strconv.Itoa(i % 10)
+			cache.Set(key, "1", []byte(key))
+			cache.Get(key)
+		}(i)
+	}
+	wg.Wait()
+
+	equalInt(t, 8, cache.Len())
+}
@@ -0,0 +1,26 @@
+package htmx
+
+import "time"
+
+type (
+	// Clock tells the time to the time dependent features, like PollLimit and Hub.BroadcastCoalesced, so tests can
+	// advance a fake clock instead of sleeping, see WithClock and htmxtest.FakeClock.
+	Clock interface {
+		// Now returns the current time.
+		Now() time.Time
+		// After returns a channel receiving the time once d has passed.
+		After(d time.Duration) <-chan time.Time
+	}
+
+	realClock struct{}
+)
+
+// Now returns time.Now.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
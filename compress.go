@@ -0,0 +1,101 @@
+package htmx
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// startCompression switches the body to gzip when the instance has WithCompression, the client accepts gzip
+// and the response carries a body that is not already encoded or streamed as server sent events.
+// net/http does not sniff the Content-Type of an encoded body, so a response without one gets text/html, Write
+// sniffs its first chunk before committing.
+func (h *Handler) startCompression(code int) {
+	if !h.compression || !bodyAllowed(code) || h.r.Method == http.MethodHead || !acceptsGzip(h.r) {
+		return
+	}
+
+	header := h.w.Header()
+	if header.Get("Content-Encoding") != "" || strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		return
+	}
+
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	header.Set("Content-Encoding", "gzip")
+	addVary(header, "Accept-Encoding")
+	header.Del("Content-Length")
+
+	h.gz = gzipWriters.Get().(*gzip.Writer)
+	h.gz.Reset(h.w)
+}
+
+// Close flushes the compressed body to the connection and finishes the gzip stream.
+// It must be called once the body is written, Release does so. Without compression Close does nothing.
+func (h *Handler) Close() error {
+	if h.gz == nil {
+		return nil
+	}
+
+	gz := h.gz
+	h.gz = nil
+
+	err := gz.Close()
+	gz.Reset(io.Discard)
+	gzipWriters.Put(gz)
+
+	return err
+}
+
+// bodyAllowed returns false for the status codes that must not carry a body.
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// acceptsGzip returns true when the Accept-Encoding request header lists gzip, or *, without a zero quality.
+// An explicit gzip entry takes precedence over *.
+func acceptsGzip(r *http.Request) bool {
+	gzipSeen, gzipOK := false, false
+	starSeen, starOK := false, false
+
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			name = strings.TrimSpace(name)
+
+			switch {
+			case strings.EqualFold(name, "gzip") && !gzipSeen:
+				gzipSeen, gzipOK = true, acceptableQuality(params)
+			case name == "*" && !starSeen:
+				starSeen, starOK = true, acceptableQuality(params)
+			}
+		}
+	}
+
+	if gzipSeen {
+		return gzipOK
+	}
+
+	return starSeen && starOK
+}
+
+// acceptableQuality returns false when the parameters of a coding give it a zero or invalid quality.
+func acceptableQuality(params string) bool {
+	q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+	if !ok {
+		return true
+	}
+
+	weight, err := strconv.ParseFloat(q, 64)
+	return err == nil && weight > 0
+}
@@ -0,0 +1,188 @@
+package htmx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fragment returns a representative 8KB html fragment
+func fragment() string {
+	names := []string{"Bulbasaur", "Charmander", "Squirtle", "Pikachu", "Jigglypuff", "Meowth", "Psyduck"}
+	types := []string{"grass", "fire", "water", "electric", "normal"}
+
+	var b strings.Builder
+	for i := 1; b.Len() < 8192; i++ {
+		fmt.Fprintf(&b, `<tr id="pokemon-%d"><td>%s</td><td class="type-%s">%s</td><td>%d</td>`+
+			`<td><button hx-get="/pokemon/%d" hx-target="#detail">show</button></td></tr>`,
+			i, names[i%len(names)], types[i%len(types)], types[i%len(types)], i*37%251, i)
+	}
+
+	return b.String()[:8192]
+}
+
+func TestCompression(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+
+	handler := New(WithCompression()).NewHandler(rec, r)
+	handler.ReTarget("#table")
+	handler.JustWriteString(fragment())
+	handler.Release()
+
+	equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	equal(t, "#table", rec.Header().Get(HXRetarget.String()))
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equal(t, fragment(), string(body))
+}
+
+func TestCompressionVary(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Vary", "HX-Request, accept-encoding")
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+
+	handler := New(WithCompression()).NewHandler(rec, r)
+	handler.JustWriteString(fragment())
+	handler.Release()
+
+	equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	equal(t, "HX-Request, accept-encoding", strings.Join(rec.Header().Values("Vary"), ","))
+}
+
+func TestCompressionSkipped(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		prepare  func(h *Handler)
+	}{
+		{"not accepted", "", nil},
+		{"zero quality", "gzip;q=0", nil},
+		{"star refused", "*;q=0", nil},
+		{"gzip refused over star", "*, gzip;q=0", nil},
+		{"sse", "gzip", func(h *Handler) { _, _ = h.SSE() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.encoding)
+
+			handler := New(WithCompression()).NewHandler(rec, r)
+			if tt.prepare != nil {
+				tt.prepare(handler)
+			}
+			handler.JustWriteString("plain")
+			handler.Release()
+
+			equal(t, "", rec.Header().Get("Content-Encoding"))
+			equalBool(t, true, strings.HasSuffix(rec.Body.String(), "plain"))
+		})
+	}
+}
+
+func TestCompressionContentType(t *testing.T) {
+	hx := New(WithCompression())
+
+	tests := []struct {
+		name        string
+		encoding    string
+		set         string
+		body        string
+		contentType string
+	}{
+		{"sniffed page", "gzip", "", "<!DOCTYPE html><html><body>page</body></html>", "text/html; charset=utf-8"},
+		{"explicit gzip over star", "*;q=0, gzip", "", "<html>page</html>", "text/html; charset=utf-8"},
+		{"set by the handler", "gzip", "text/csv", "a,b", "text/csv"},
+		{"flushed without data", "gzip", "", "", "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.encoding)
+			rec := httptest.NewRecorder()
+
+			hx.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.set != "" {
+					w.Header().Set("Content-Type", tt.set)
+				}
+				if tt.body == "" {
+					w.(http.Flusher).Flush()
+					return
+				}
+				_, _ = w.Write([]byte(tt.body))
+			})).ServeHTTP(rec, r)
+
+			equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+			equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func BenchmarkCompression(b *testing.B) {
+	htmx := New(WithCompression())
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	body := fragment()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	rec := httptest.NewRecorder()
+	for i := 0; i < b.N; i++ {
+		rec = httptest.NewRecorder()
+		handler := htmx.NewHandler(rec, r)
+		handler.JustWriteString(body)
+		handler.Release()
+	}
+
+	b.ReportMetric(float64(rec.Body.Len())/float64(len(body))*100, "%size")
+}
+
+func TestFlushCompressed(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler := New(WithCompression()).NewHandler(rec, r)
+
+	_, _ = handler.WriteString("<p>first</p>")
+	if err := handler.FlushError(); err != nil {
+		t.Fatal(err)
+	}
+
+	equalBool(t, true, rec.Flushed)
+	equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+	zr, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushed := make([]byte, len("<p>first</p>"))
+	if _, err := io.ReadFull(zr, pushed); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "<p>first</p>", string(pushed))
+
+	handler = New().NewHandler(dummyWriter{}, httptest.NewRequest(http.MethodGet, "/", nil))
+	equalBool(t, true, errors.Is(handler.FlushError(), ErrFlushNotSupported))
+}
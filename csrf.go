@@ -0,0 +1,55 @@
+package htmx
+
+import (
+	"net/http"
+)
+
+// CSRFTokenHeader is the response header carrying the current csrf token on safe requests,
+// htmx can echo it back with hx-headers or a htmx:configRequest listener.
+const CSRFTokenHeader = "X-CSRF-Token"
+
+// CSRF protects unsafe requests with pluggable token extraction and validation, so it fits gorilla/csrf or custom schemes.
+// On safe methods the current token from getToken is sent in the CSRFTokenHeader response header.
+// On unsafe methods a request failing validate is rejected with 403 Forbidden, retargeted to the error target, see WithErrorTarget.
+// htmx does not swap error responses unless configured to, see https://htmx.org/docs/#response-handling.
+func (h *HTMX) CSRF(getToken func(*http.Request) string, validate func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isSafeMethod(r.Method) {
+				if token := getToken(r); token != "" {
+					w.Header().Set(CSRFTokenHeader, token)
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if validate(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			handler, ok := FromContext(r.Context())
+			if !ok {
+				handler = h.newHandler(w, r)
+				defer handler.Release()
+			}
+
+			handler.log.Warn("htmx: csrf validation failed", "method", r.Method, "path", r.URL.Path)
+
+			handler.ReTarget(handler.errorTarget)
+			handler.WriteHeader(http.StatusForbidden)
+			handler.JustWriteString(http.StatusText(http.StatusForbidden))
+		})
+	}
+}
+
+// isSafeMethod returns true for the methods that should not change state, see RFC 9110 section 9.2.1.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
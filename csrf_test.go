@@ -0,0 +1,36 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	csrf := New().CSRF(
+		func(*http.Request) string { return "token" },
+		func(r *http.Request) bool { return r.Header.Get(CSRFTokenHeader) == "token" },
+	)
+
+	next := csrf(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "token", rec.Header().Get(CSRFTokenHeader))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(CSRFTokenHeader, "token")
+	rec = httptest.NewRecorder()
+	next.ServeHTTP(rec, r)
+	equalInt(t, http.StatusOK, rec.Code)
+
+	r = httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(CSRFTokenHeader, "wrong")
+	rec = httptest.NewRecorder()
+	next.ServeHTTP(rec, r)
+	equalInt(t, http.StatusForbidden, rec.Code)
+	equal(t, DefaultErrorTarget, rec.Header().Get(HXRetarget.String()))
+}
@@ -0,0 +1,81 @@
+package htmx
+
+import (
+	"net/http"
+	"time"
+)
+
+// DedupNonceHeader is the request header carrying the client nonce DedupBoosted keys requests on by default,
+// e.g. set with hx-headers or a htmx:configRequest listener.
+const DedupNonceHeader = "X-Request-Nonce"
+
+type (
+	// DedupOption configures DedupBoosted.
+	DedupOption func(*boostDedup)
+
+	boostDedup struct {
+		window time.Duration
+		key    func(*http.Request) string
+	}
+)
+
+// WithDedupWindow sets how long DedupBoosted answers a repeated request with the first response, one second by
+// default.
+func WithDedupWindow(window time.Duration) DedupOption {
+	return func(d *boostDedup) {
+		d.window = window
+	}
+}
+
+// WithDedupKey sets the function identifying a request, the DedupNonceHeader value and the path by default.
+// An empty key disables the deduplication of the request.
+func WithDedupKey(key func(*http.Request) string) DedupOption {
+	return func(d *boostDedup) {
+		d.key = key
+	}
+}
+
+// DedupBoosted suppresses boosted GET requests fired twice for the same navigation, which some browsers and
+// extensions do on fast clicks: within the window the duplicate gets the response of the first request,
+// waiting for it when it still runs, instead of running next again. It is narrower than Idempotent, other
+// requests and requests without a nonce are passed to next. NewMemoryIdempotencyStore provides an in-memory store.
+// DedupBoosted records what is written to the http.ResponseWriter, place it before the Middleware.
+func (h *HTMX) DedupBoosted(store IdempotencyStore, opts ...DedupOption) func(http.Handler) http.Handler {
+	d := &boostDedup{
+		window: time.Second,
+		key:    dedupNonceKey,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	replay := newReplayer(h, store)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || !h.IsHxBoosted(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := d.key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			replay.serve(w, r, "boosted "+key, d.window, next)
+		})
+	}
+}
+
+// dedupNonceKey returns the DedupNonceHeader value and the path of r, empty without nonce.
+func dedupNonceKey(r *http.Request) string {
+	nonce := r.Header.Get(DedupNonceHeader)
+	if nonce == "" {
+		return ""
+	}
+
+	return nonce + " " + r.URL.Path
+}
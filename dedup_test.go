@@ -0,0 +1,90 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDedupBoosted(t *testing.T) {
+	calls := 0
+	dedup := New().DedupBoosted(NewMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte("page " + strconv.Itoa(calls)))
+	}))
+
+	serve := func(boosted bool, nonce string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/pokemon", nil)
+		r.Header.Set("HX-Request", "true")
+		if boosted {
+			r.Header.Set("HX-Boosted", "true")
+		}
+		if nonce != "" {
+			r.Header.Set(DedupNonceHeader, nonce)
+		}
+
+		rec := httptest.NewRecorder()
+		dedup.ServeHTTP(rec, r)
+		return rec
+	}
+
+	equal(t, "page 1", serve(true, "n1").Body.String())
+	equal(t, "page 1", serve(true, "n1").Body.String())
+	equal(t, "page 2", serve(true, "n2").Body.String())
+	equal(t, "page 3", serve(false, "n1").Body.String())
+	equal(t, "page 4", serve(true, "").Body.String())
+	equalInt(t, 4, calls)
+}
+
+func TestReplayerWaitsForInFlight(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	waiting := make(chan struct{})
+
+	replay := newReplayer(New(), NewMemoryIdempotencyStore())
+	replay.waiting = func(string) { close(waiting) }
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("page " + strconv.Itoa(n)))
+	})
+
+	recs := []*httptest.ResponseRecorder{httptest.NewRecorder(), httptest.NewRecorder()}
+	serve := func(rec *httptest.ResponseRecorder) {
+		replay.serve(rec, httptest.NewRequest(http.MethodGet, "/pokemon", nil), "boosted n1", time.Second, next)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		serve(recs[0])
+	}()
+	<-started
+
+	go func() {
+		defer wg.Done()
+		serve(recs[1])
+	}()
+	<-waiting
+
+	close(release)
+	wg.Wait()
+
+	equalInt(t, 1, calls)
+	equal(t, "page 1", recs[0].Body.String())
+	equal(t, "page 1", recs[1].Body.String())
+}
@@ -0,0 +1,76 @@
+package htmx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WriteWithETag writes the body with a strong ETag computed from its content, polling endpoints returning an
+// unchanged fragment then cost a 304 Not Modified without body. HX-Request is added to the Vary header
+// so full page and partial responses never share a cache entry.
+// A strong validator must differ per content coding, the tag of a gzip encoded body, see WithCompression,
+// carries a -gzip suffix.
+func (h *Handler) WriteWithETag(body []byte) (n int, err error) {
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:16])
+	if h.compression && acceptsGzip(h.r) && h.w.Header().Get("Content-Encoding") == "" {
+		tag += "-gzip"
+		h.Vary("Accept-Encoding")
+	}
+	etag := `"` + tag + `"`
+
+	h.w.Header().Set("ETag", etag)
+	h.Vary(h.requestPrefix + HxRequestHeaderRequest.String())
+
+	if etagMatch(h.r.Header.Get("If-None-Match"), etag) {
+		h.WriteHeader(http.StatusNotModified)
+		return 0, nil
+	}
+
+	return h.Write(body)
+}
+
+// NotModifiedIf sets Last-Modified to lastModified and, when a GET or HEAD request carries an If-Modified-Since
+// at or after it, commits a 304 Not Modified and returns true, the handler should return then. Boosted
+// navigations back and forth to an unchanged page cost no body that way. Like WriteWithETag it adds HX-Request
+// to Vary so full page and partial responses never share a cache entry. An If-None-Match header takes precedence,
+// as RFC 9110 requires, If-Modified-Since is ignored then. A zero lastModified returns false without header.
+func (h *Handler) NotModifiedIf(lastModified time.Time) bool {
+	if lastModified.IsZero() {
+		return false
+	}
+
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	h.w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	h.Vary(h.requestPrefix + HxRequestHeaderRequest.String())
+
+	if (h.r.Method != http.MethodGet && h.r.Method != http.MethodHead) || h.r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+
+	since, err := http.ParseTime(h.r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.After(since) {
+		return false
+	}
+
+	h.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatch returns true when the If-None-Match value lists the etag, using the weak comparison of RFC 9110 section 13.1.2.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
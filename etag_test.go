@@ -0,0 +1,111 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteWithETag(t *testing.T) {
+	htmx := New()
+	body := []byte("<li>unchanged</li>")
+
+	rec := httptest.NewRecorder()
+	_, err := htmx.NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil)).WriteWithETag(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	etag := rec.Header().Get("ETag")
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, string(body), rec.Body.String())
+	equal(t, "HX-Request", rec.Header().Get("Vary"))
+	equalBool(t, true, etag != "")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", `"other", `+etag)
+	rec = httptest.NewRecorder()
+	handler := htmx.NewHandler(rec, r)
+	handler.ReTarget("#list")
+	_, _ = handler.WriteWithETag(body)
+
+	equalInt(t, http.StatusNotModified, rec.Code)
+	equal(t, "", rec.Body.String())
+	equal(t, etag, rec.Header().Get("ETag"))
+	equal(t, "#list", rec.Header().Get(HXRetarget.String()))
+}
+
+func TestWriteWithETagVary(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Add("Vary", "Accept-Encoding, HX-Request")
+
+	_, _ = New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil)).WriteWithETag([]byte("body"))
+
+	equalInt(t, 1, len(rec.Header().Values("Vary")))
+}
+
+func TestWriteWithETagCompressed(t *testing.T) {
+	body := []byte("<li>unchanged</li>")
+
+	serve := func(h *HTMX, acceptGzip bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if acceptGzip {
+			r.Header.Set("Accept-Encoding", "gzip")
+		}
+
+		rec := httptest.NewRecorder()
+		handler := h.NewHandler(rec, r)
+		_, _ = handler.WriteWithETag(body)
+		handler.Release()
+		return rec
+	}
+
+	identity := serve(New(WithCompression()), false)
+	compressed := serve(New(WithCompression()), true)
+
+	equal(t, "gzip", compressed.Header().Get("Content-Encoding"))
+	equalBool(t, true, identity.Header().Get("ETag") != compressed.Header().Get("ETag"))
+	equal(t, strings.TrimSuffix(identity.Header().Get("ETag"), `"`)+`-gzip"`, compressed.Header().Get("ETag"))
+	equal(t, identity.Header().Get("ETag"), serve(New(), true).Header().Get("ETag"))
+}
+
+func TestNotModifiedIf(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+
+	serve := func(header, value string) (*httptest.ResponseRecorder, bool) {
+		r := httptest.NewRequest(http.MethodGet, "/page", nil)
+		r.Header.Set(HxRequestHeaderBoosted.String(), "true")
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+
+		rec := httptest.NewRecorder()
+		handler := New().NewHandler(rec, r)
+		notModified := handler.NotModifiedIf(modified)
+		if !notModified {
+			_, _ = handler.WriteString("<main>page</main>")
+		}
+		return rec, notModified
+	}
+
+	rec, notModified := serve("If-Modified-Since", modified.Format(http.TimeFormat))
+	equalBool(t, true, notModified)
+	equalInt(t, http.StatusNotModified, rec.Code)
+	equalInt(t, 0, rec.Body.Len())
+	equal(t, "HX-Request", rec.Header().Get("Vary"))
+
+	rec, notModified = serve("If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat))
+	equalBool(t, false, notModified)
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", rec.Header().Get("Last-Modified"))
+	equal(t, "HX-Request", rec.Header().Get("Vary"))
+	equal(t, "<main>page</main>", rec.Body.String())
+
+	_, notModified = serve("", "")
+	equalBool(t, false, notModified)
+
+	_, notModified = serve("If-None-Match", `"other"`)
+	equalBool(t, false, notModified)
+}
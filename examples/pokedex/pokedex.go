@@ -2,11 +2,13 @@ package main
 
 import (
 	"encoding/json"
-	"github.com/developersismedika/go-htmx/middleware"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+
+	"github.com/developersismedika/go-htmx"
+	"github.com/developersismedika/go-htmx/middleware"
 )
 
 type (
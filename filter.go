@@ -0,0 +1,17 @@
+package htmx
+
+type (
+	// FragmentFilter rewrites the output of a handler before it is sent, see WithFragmentFilters.
+	FragmentFilter interface {
+		// Filter returns the data to send instead of p, an error aborts the write.
+		Filter(p []byte) ([]byte, error)
+	}
+
+	// FragmentFilterFunc adapts a function to a FragmentFilter.
+	FragmentFilterFunc func(p []byte) ([]byte, error)
+)
+
+// Filter calls f(p).
+func (f FragmentFilterFunc) Filter(p []byte) ([]byte, error) {
+	return f(p)
+}
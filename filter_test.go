@@ -0,0 +1,44 @@
+package htmx
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFragmentFilters(t *testing.T) {
+	cdn := FragmentFilterFunc(func(p []byte) ([]byte, error) {
+		return bytes.ReplaceAll(p, []byte(`"/static/`), []byte(`"/cdn/static/`)), nil
+	})
+	upper := FragmentFilterFunc(func(p []byte) ([]byte, error) {
+		return bytes.ReplaceAll(p, []byte("cdn"), []byte("CDN")), nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler := New(WithFragmentFilters(cdn, upper)).NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := `<img src="/static/logo.png">`
+	n, err := handler.WriteString(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equalInt(t, len(body), n)
+	equal(t, `<img src="/CDN/static/logo.png">`, rec.Body.String())
+}
+
+func TestFragmentFilterError(t *testing.T) {
+	failed := errors.New("bad fragment")
+	failing := FragmentFilterFunc(func([]byte) ([]byte, error) { return nil, failed })
+
+	rec := httptest.NewRecorder()
+	handler := New(WithFragmentFilters(failing)).NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	_, err := handler.WriteString("<p>hi</p>")
+
+	equalBool(t, true, errors.Is(err, failed))
+	equalBool(t, false, handler.isCommitted())
+	equalInt(t, 0, rec.Body.Len())
+}
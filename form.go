@@ -0,0 +1,174 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFormValue is returned by Handler.DecodeForm when a form value does not fit the type of its field.
+var ErrInvalidFormValue = errors.New("htmx: invalid form value")
+
+// FormValue returns the first value of the form field key, from the query or the body, as
+// http.Request.FormValue does. The body is parsed once, up to DefaultFormMaxBytes, see WithFormMaxBytes, a body
+// that can not be parsed is logged and only the values read so far are looked up.
+func (h *Handler) FormValue(key string) string {
+	if err := h.parseForm(); err != nil {
+		h.log.Warn("htmx: unable to parse the form", "error", err)
+	}
+
+	if vs := h.r.Form[key]; len(vs) > 0 {
+		return vs[0]
+	}
+
+	return ""
+}
+
+// DecodeForm parses the form, like FormValue, and stores its values in the struct v points to.
+// A field takes the values of the form field named by its `form` tag, or by its own name without tag, `form:"-"`
+// skips it. Fields without a form value keep theirs. Strings, bools, where a checkbox sending "on" is true,
+// integers, floats, pointers to them and slices of them, for repeated fields, are supported.
+// A value not fitting its field returns an error wrapping ErrInvalidFormValue that names the field.
+func (h *Handler) DecodeForm(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("htmx: DecodeForm expects a pointer to a struct, got %T", v)
+	}
+
+	if err := h.parseForm(); err != nil {
+		return err
+	}
+
+	return decodeForm(rv.Elem(), h.r.Form)
+}
+
+// parseForm parses the query and the body of the request once, reading at most formMaxBytes of the body.
+func (h *Handler) parseForm() error {
+	if h.r.Form != nil {
+		return nil
+	}
+
+	if h.r.Body != nil && h.formMaxBytes > 0 {
+		h.r.Body = http.MaxBytesReader(h.w, h.r.Body, h.formMaxBytes)
+	}
+
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(h.r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		err = h.r.ParseMultipartForm(h.formMaxBytes)
+	} else {
+		err = h.r.ParseForm()
+	}
+	if err != nil {
+		return fmt.Errorf("htmx: unable to parse the form: %w", err)
+	}
+
+	return nil
+}
+
+// decodeForm sets the fields of the struct rv from the form values, embedded structs included.
+func decodeForm(rv reflect.Value, form map[string][]string) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := decodeForm(rv.Field(i), form); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		values, ok := form[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		if err := setFormField(rv.Field(i), values); err != nil {
+			return fmt.Errorf("%w: field %q: %v", ErrInvalidFormValue, name, err)
+		}
+	}
+
+	return nil
+}
+
+// setFormField stores the values in the field, slices get all of them, other types the first one.
+func setFormField(fv reflect.Value, values []string) error {
+	switch fv.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, val := range values {
+			if err := setFormValue(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	case reflect.Pointer:
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setFormValue(ptr.Elem(), values[0]); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	default:
+		return setFormValue(fv, values[0])
+	}
+}
+
+// setFormValue parses val into fv according to its kind.
+func setFormValue(fv reflect.Value, val string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		if val == "on" {
+			fv.SetBool(true)
+			return nil
+		}
+
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("expects a bool, got %q", val)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(val), 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expects an integer of %d bits, got %q", fv.Type().Bits(), val)
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(val), 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expects an unsigned integer of %d bits, got %q", fv.Type().Bits(), val)
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expects a number, got %q", val)
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return nil
+}
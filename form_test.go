@@ -0,0 +1,74 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newFormHandler(h *HTMX, form url.Values) *Handler {
+	r := httptest.NewRequest(http.MethodPost, "/pokemon?page=2", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return h.NewHandler(httptest.NewRecorder(), r)
+}
+
+func TestDecodeForm(t *testing.T) {
+	type pokemon struct {
+		Name     string   `form:"name"`
+		Level    int      `form:"level"`
+		Shiny    bool     `form:"shiny"`
+		Moves    []string `form:"move"`
+		Weight   *float64 `form:"weight"`
+		Page     uint8    `form:"page"`
+		Nickname string
+		Secret   string `form:"-"`
+	}
+
+	handler := newFormHandler(New(), url.Values{
+		"name":     {"Pikachu"},
+		"level":    {"25"},
+		"shiny":    {"on"},
+		"move":     {"Thunderbolt", "Quick Attack"},
+		"weight":   {"6.0"},
+		"Nickname": {"Sparky"},
+		"-":        {"nope"},
+		"Secret":   {"nope"},
+	})
+
+	p := pokemon{Secret: "kept"}
+	if err := handler.DecodeForm(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	equal(t, "Pikachu", p.Name)
+	equalInt(t, 25, p.Level)
+	equalBool(t, true, p.Shiny)
+	equal(t, "Thunderbolt,Quick Attack", strings.Join(p.Moves, ","))
+	equalBool(t, true, p.Weight != nil && *p.Weight == 6)
+	equalInt(t, 2, int(p.Page))
+	equal(t, "Sparky", p.Nickname)
+	equal(t, "kept", p.Secret)
+	equal(t, "Pikachu", handler.FormValue("name"))
+	equal(t, "", handler.FormValue("missing"))
+}
+
+func TestDecodeFormErrors(t *testing.T) {
+	var p struct {
+		Level int `form:"level"`
+	}
+
+	handler := newFormHandler(New(), url.Values{"level": {"high"}})
+	err := handler.DecodeForm(&p)
+	equalBool(t, true, errors.Is(err, ErrInvalidFormValue))
+	equalBool(t, true, strings.Contains(err.Error(), `"level"`))
+
+	equalBool(t, true, handler.DecodeForm(p) != nil)
+
+	handler = newFormHandler(New(WithFormMaxBytes(8)), url.Values{"name": {"a long name"}})
+	equalBool(t, true, handler.DecodeForm(&p) != nil)
+	equal(t, "", handler.FormValue("name"))
+}
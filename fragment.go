@@ -0,0 +1,75 @@
+package htmx
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FragmentMux serves one url rendering a different fragment depending on the element htmx targets.
+// Partial requests are dispatched on their HX-Target header, every other request goes to the full page handler.
+type FragmentMux struct {
+	htmx      *HTMX
+	full      http.Handler
+	mu        sync.RWMutex
+	fragments map[string]func(*Handler) error
+}
+
+// NewFragmentMux returns a FragmentMux sending full page, boosted and history restore requests to full.
+// A nil full responds with 404 Not Found to those requests.
+func (h *HTMX) NewFragmentMux(full http.Handler) *FragmentMux {
+	if full == nil {
+		full = http.NotFoundHandler()
+	}
+
+	return &FragmentMux{
+		htmx:      h,
+		full:      full,
+		fragments: make(map[string]func(*Handler) error),
+	}
+}
+
+// Handle registers the render function for partial requests targeting the element with the given id,
+// a leading "#" is ignored. Registering an id twice replaces the render function.
+func (m *FragmentMux) Handle(targetID string, render func(*Handler) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fragments[strings.TrimPrefix(targetID, "#")] = render
+}
+
+// ServeHTTP dispatches the request, see FragmentMux. Partial requests for an unknown target get 404 Not Found,
+// a failing render function is logged and gets 500 Internal Server Error unless it wrote a response already.
+// The Handler stored by the Middleware is reused when present.
+func (m *FragmentMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, ok := FromContext(r.Context())
+	if !ok {
+		handler = m.htmx.NewHandler(w, r)
+		defer handler.Release()
+	}
+
+	if handler.RenderMode() != ModePartial {
+		m.full.ServeHTTP(w, r)
+		return
+	}
+
+	target := handler.Target()
+
+	m.mu.RLock()
+	render, ok := m.fragments[target]
+	m.mu.RUnlock()
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("htmx: no fragment registered for target %q", target), http.StatusNotFound)
+		return
+	}
+
+	if err := render(handler); err != nil {
+		handler.log.Error("htmx: unable to render fragment", "target", target, "error", err)
+
+		if !handler.isCommitted() {
+			handler.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFragmentMux(t *testing.T) {
+	full := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>page</html>"))
+	})
+
+	mux := New().NewFragmentMux(full)
+	mux.Handle("#list", func(h *Handler) error {
+		_, err := h.WriteString("<li>item</li>")
+		return err
+	})
+	mux.Handle("detail", func(h *Handler) error {
+		return errors.New("database gone")
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+		body    string
+	}{
+		{"full page", nil, http.StatusOK, "<html>page</html>"},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true", "HX-Target": "list"}, http.StatusOK, "<html>page</html>"},
+		{"fragment", map[string]string{"HX-Request": "true", "HX-Target": "list"}, http.StatusOK, "<li>item</li>"},
+		{"unknown target", map[string]string{"HX-Request": "true", "HX-Target": "other"}, http.StatusNotFound, "htmx: no fragment registered for target \"other\"\n"},
+		{"render error", map[string]string{"HX-Request": "true", "HX-Target": "detail"}, http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, r)
+
+			equalInt(t, tt.code, rec.Code)
+			equal(t, tt.body, rec.Body.String())
+		})
+	}
+}
+
+func TestFragmentMuxMiddleware(t *testing.T) {
+	htmx := New()
+	mux := htmx.NewFragmentMux(nil)
+	mux.Handle("list", func(h *Handler) error {
+		h.ReTarget("#items")
+		_, err := h.WriteString("<li>item</li>")
+		return err
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Target", "list")
+
+	rec := httptest.NewRecorder()
+	htmx.Middleware(mux).ServeHTTP(rec, r)
+
+	equal(t, "#items", rec.Header().Get(HXRetarget.String()))
+	equal(t, "HX-Request", rec.Header().Get("Vary"))
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	equalInt(t, http.StatusNotFound, rec.Code)
+}
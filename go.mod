@@ -1,7 +1,3 @@
 module github.com/developersismedika/go-htmx
 
 go 1.20
-
-require go.uber.org/zap v1.26.0
-
-require go.uber.org/multierr v1.10.0 // indirect
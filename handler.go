@@ -1,20 +1,76 @@
 package htmx
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"net"
 	"net/http"
-
-	"go.uber.org/zap"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type (
+	// commitState guards the commit of a Handler, kept behind a pointer so Buffered can copy the handler.
+	commitState struct {
+		once sync.Once
+		done atomic.Bool
+	}
+
 	Handler struct {
-		log      *zap.Logger
-		w        http.ResponseWriter
-		r        *http.Request
-		request  HxRequestHeader
-		response *HxResponseHeader
+		log             Logger
+		observer        Observer
+		w               http.ResponseWriter
+		r               *http.Request
+		request         HxRequestHeader
+		currentURL      *url.URL
+		triggers        map[HxResponseKey]*Trigger
+		response        *HxResponseHeader
+		state           *commitState
+		status          int
+		oob             *OOB
+		pool            *sync.Pool
+		swapDuration    time.Duration
+		settleDelay     time.Duration
+		notificationKey string
+		errorTarget     string
+		detailHeader    string
+		confirmHeader   string
+		compression     bool
+		gz              *gzip.Writer
+		triggerEncoder  func(any) ([]byte, error)
+		triggerTiming   HxResponseKey
+		preload         bool
+		reads           []HxRequestHeaderKey
+		sseTriggerEvent string
+		headerless      bool
+		triggerLimit    int
+		triggerOverflow TriggerOverflow
+		requestPrefix   string
+		clientHeader    string
+		formMaxBytes    int64
+		bodyLimit       *limitedBody
+		fragmentHeader  string
+		partialNoStore  bool
+		oobCollision    OOBCollision
+		renderModeDebug bool
+		orderedTriggers bool
+		filters         []FragmentFilter
+		onCommit        []func(status int)
+
+		notificationSchema func(level, message string) any
+
+		fragmentCache       FragmentCache
+		fragmentCacheHxOnly bool
+		boostedFragments    bool
 	}
 )
 
@@ -25,28 +81,337 @@ const (
 
 // IsHxRequest returns true if the request is a htmx request.
 func (h *Handler) IsHxRequest() bool {
+	h.markRead(HxRequestHeaderRequest)
 	return h.request.HxRequest
 }
 
 // IsHxBoosted returns true if the request is a htmx request and the request is boosted
 func (h *Handler) IsHxBoosted() bool {
+	h.markRead(HxRequestHeaderBoosted)
 	return h.request.HxBoosted
 }
 
+// IsPreload returns true if the request is a speculative request of the htmx preload extension, marked with the
+// preload header of the htmx instance, see WithPreloadHeader. Such a request should not have side effects, like
+// marking a notification read because the user hovered a link.
+func (h *Handler) IsPreload() bool {
+	return h.preload
+}
+
 // IsHxHistoryRestoreRequest returns true if the request is a htmx request and the request is a history restore request
 func (h *Handler) IsHxHistoryRestoreRequest() bool {
+	h.markRead(HxRequestHeaderHistoryRestoreRequest)
 	return h.request.HxHistoryRestoreRequest
 }
 
+// IsHistoryRestore returns true for a history restore request, htmx then expects the full page,
+// boosted or not. It is IsHxHistoryRestoreRequest under a shorter name.
+func (h *Handler) IsHistoryRestore() bool {
+	return h.IsHxHistoryRestoreRequest()
+}
+
+// ServeFullOnRestore serves a history restore request with full and returns true, the handler should return then.
+// Other requests are left alone and it returns false.
+func (h *Handler) ServeFullOnRestore(full http.HandlerFunc) bool {
+	if !h.IsHistoryRestore() {
+		return false
+	}
+
+	full(h, h.r)
+	return true
+}
+
 // RenderPartial returns true if the request is an HTMX request that is either boosted or a standard request,
 // provided it is not a history restore request.
 func (h *Handler) RenderPartial() bool {
-	return (h.request.HxRequest || h.request.HxBoosted) && !h.request.HxHistoryRestoreRequest
+	h.markRead(HxRequestHeaderRequest, HxRequestHeaderBoosted, HxRequestHeaderHistoryRestoreRequest)
+	return RenderPartialFromHeader(h.request)
+}
+
+// RenderMode returns how much of the page the request expects, see the package level RenderMode.
+func (h *Handler) RenderMode() Mode {
+	h.markRead(HxRequestHeaderRequest, HxRequestHeaderBoosted, HxRequestHeaderHistoryRestoreRequest)
+	return RenderModeFromHeader(h.request)
+}
+
+// Boosted returns the parsed HX-Boosted request header, false when absent.
+func (h *Handler) Boosted() bool {
+	h.markRead(HxRequestHeaderBoosted)
+	return h.request.HxBoosted
+}
+
+// CurrentURL returns the HX-Current-URL request header, the current URL of the browser.
+// A response built from it depends on the page it is requested from, so HX-Current-URL is added to Vary.
+func (h *Handler) CurrentURL() string {
+	h.markRead(HxRequestHeaderCurrentURL)
+	h.Vary(h.requestPrefix + HxRequestHeaderCurrentURL.String())
+	return h.request.HxCurrentURL
+}
+
+// CurrentURLParsed returns the parsed HX-Current-URL request header, e.g. to keep the query of the page when
+// rendering a fragment. It returns nil, nil when the header is absent and an error when it is malformed.
+// The result is cached, repeated calls do not parse again. Like CurrentURL it adds HX-Current-URL to Vary.
+func (h *Handler) CurrentURLParsed() (*url.URL, error) {
+	current := h.CurrentURL()
+	if h.currentURL != nil || current == "" {
+		return h.currentURL, nil
+	}
+
+	u, err := url.Parse(current)
+	if err != nil {
+		return nil, fmt.Errorf("htmx: invalid %s header: %w", HxRequestHeaderCurrentURL, err)
+	}
+
+	h.currentURL = u
+	return u, nil
+}
+
+// RequestHeadersMap returns the htmx request headers the request carries, keyed by their htmx name without the
+// prefix of WithRequestHeaderPrefix, e.g. for a request scoped log entry. Empty headers are left out.
+// Unlike the accessors it does not count as a read for VaryFromRequest.
+func (h *Handler) RequestHeadersMap() map[string]string {
+	headers := make(map[string]string)
+	for _, k := range hxRequestHeaderKeys {
+		if v := h.r.Header.Get(h.requestPrefix + k.String()); v != "" {
+			headers[k.String()] = v
+		}
+	}
+
+	return headers
+}
+
+// ClientInfo returns the version and extensions the client claims in the client info header, DefaultClientInfoHeader
+// unless set with WithClientInfoHeader, see ParseClientInfo, the zero ClientInfo without header. htmx does
+// not send such a header, the page has to, and any client can claim anything: use it to gate features for older
+// clients, never for security decisions.
+func (h *Handler) ClientInfo() ClientInfo {
+	return ParseClientInfo(h.r.Header.Get(h.clientHeader))
+}
+
+// ActiveNav returns the candidate path the page of the request falls under, to mark the active item of a
+// navigation: the longest candidate that is the path of the current URL, see CurrentURLParsed, or one of its
+// parents, "/users" matches "/users/1" but not "/usersettings". Requests without a valid HX-Current-URL, like full
+// page loads, use the path of the request. It returns "" when no candidate matches.
+func (h *Handler) ActiveNav(candidates ...string) string {
+	path := h.r.URL.Path
+	if current, err := h.CurrentURLParsed(); err == nil && current != nil {
+		path = current.Path
+	}
+
+	active := ""
+	for _, c := range candidates {
+		if len(c) > len(active) && pathUnder(path, c) {
+			active = c
+		}
+	}
+
+	return active
+}
+
+// pathUnder returns true when path is prefix or below it, segment wise.
+func pathUnder(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// HistoryRestoreRequest returns the parsed HX-History-Restore-Request request header, false when absent.
+func (h *Handler) HistoryRestoreRequest() bool {
+	h.markRead(HxRequestHeaderHistoryRestoreRequest)
+	return h.request.HxHistoryRestoreRequest
+}
+
+// Prompt returns the HX-Prompt request header, the user response to an hx-prompt.
+func (h *Handler) Prompt() string {
+	h.markRead(HxRequestHeaderPrompt)
+	return h.request.HxPrompt
+}
+
+// PromptValue returns the user response to an hx-prompt and whether the HX-Prompt request header was sent,
+// an empty answer is present. Percent encoded values are decoded.
+func (h *Handler) PromptValue() (string, bool) {
+	h.markRead(HxRequestHeaderPrompt)
+	if _, ok := h.r.Header[http.CanonicalHeaderKey(h.requestPrefix+HxRequestHeaderPrompt.String())]; !ok {
+		return "", false
+	}
+
+	prompt := h.request.HxPrompt
+	if decoded, err := url.PathUnescape(prompt); err == nil {
+		prompt = decoded
+	}
+
+	return prompt, true
+}
+
+// RequirePrompt returns the user response to an hx-prompt, or ErrPromptMissing when the HX-Prompt request header
+// was not sent, handlers usually respond with 400 Bad Request then.
+func (h *Handler) RequirePrompt() (string, error) {
+	prompt, ok := h.PromptValue()
+	if !ok {
+		return "", ErrPromptMissing
+	}
+
+	return prompt, nil
+}
+
+// RequestHeader returns the parsed HX-Request request header, false when absent.
+func (h *Handler) RequestHeader() bool {
+	h.markRead(HxRequestHeaderRequest)
+	return h.request.HxRequest
+}
+
+// Target returns the HX-Target request header, the id of the target element if it exists.
+func (h *Handler) Target() string {
+	h.markRead(HxRequestHeaderTarget)
+	return h.request.HxTarget
+}
+
+// TargetAllowed returns true when the HX-Target request header is one of the allowed element ids, which may be
+// given with or without a leading "#". A request without target is allowed, it renders the default response.
+// Use it as a defense in depth when the target decides what to render, see HTMX.RequireTarget.
+func (h *Handler) TargetAllowed(allowed ...string) bool {
+	return targetAllowed(h.Target(), allowed)
+}
+
+func targetAllowed(target string, allowed []string) bool {
+	if target == "" {
+		return true
+	}
+
+	for _, a := range allowed {
+		if strings.TrimPrefix(a, "#") == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TriggerName returns the HX-Trigger-Name request header, the name of the triggered element if it exists.
+func (h *Handler) TriggerName() string {
+	h.markRead(HxRequestHeaderTriggerName)
+	return h.request.HxTriggerName
+}
+
+// TriggerID returns the HX-Trigger request header, the id of the triggered element if it exists.
+// It is not named Trigger because that method sets the HX-Trigger response header.
+func (h *Handler) TriggerID() string {
+	h.markRead(HxRequestHeaderTrigger)
+	return h.request.HxTrigger
+}
+
+// TriggerMatches returns true when the element triggering the request has the given id, from HX-Trigger, or the
+// given name, from HX-Trigger-Name, so handlers can dispatch on whichever the template sets. An empty id or name is
+// not compared. The comparison is exact, ids and names are case sensitive in HTML.
+func (h *Handler) TriggerMatches(id, name string) bool {
+	return (id != "" && h.TriggerID() == id) || (name != "" && h.TriggerName() == name)
+}
+
+// TriggerNameIs returns true when the HX-Trigger-Name request header matches one of names, so a form with several
+// submit buttons or fields can dispatch on the control that triggered the request. The match is exact once the
+// header and the names are trimmed of surrounding spaces, an empty header matches nothing.
+func (h *Handler) TriggerNameIs(names ...string) bool {
+	name := strings.TrimSpace(h.TriggerName())
+	if name == "" {
+		return false
+	}
+
+	for _, n := range names {
+		if strings.TrimSpace(n) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TriggerJSON decodes the HX-Trigger request header into v when it holds a JSON object, which some setups send
+// instead of the element id. It returns ErrTriggerNotJSON, leaving v untouched, for a plain id, also one that
+// merely starts with "{". TriggerID keeps returning the raw value.
+func (h *Handler) TriggerJSON(v any) error {
+	h.markRead(HxRequestHeaderTrigger)
+	raw := []byte(strings.TrimSpace(h.request.HxTrigger))
+	if len(raw) == 0 || raw[0] != '{' || !json.Valid(raw) {
+		return ErrTriggerNotJSON
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("htmx: invalid %s header: %w", HxRequestHeaderTrigger, err)
+	}
+
+	return nil
+}
+
+// TriggeringElement returns the id, from HX-Trigger, and the name, from HX-Trigger-Name, of the element that
+// triggered the request. Either one is empty when the element has no such attribute.
+func (h *Handler) TriggeringElement() (id, name string) {
+	h.markRead(HxRequestHeaderTrigger, HxRequestHeaderTriggerName)
+	return strings.TrimSpace(h.request.HxTrigger), strings.TrimSpace(h.request.HxTriggerName)
+}
+
+// TriggerDetail decodes the JSON event detail sent by the client in the event detail request header,
+// see WithEventDetailHeader, into v. It returns ErrNoEventDetail, leaving v untouched, when the header is absent.
+func (h *Handler) TriggerDetail(v any) error {
+	detail := h.r.Header.Get(h.detailHeader)
+	if detail == "" {
+		return ErrNoEventDetail
+	}
+
+	if err := json.Unmarshal([]byte(detail), v); err != nil {
+		return fmt.Errorf("htmx: invalid %s header: %w", h.detailHeader, err)
+	}
+
+	return nil
 }
 
 // Write writes the data to the connection as part of an HTTP reply.
+// The first call commits the status code, 200 OK unless WriteHeader was called, and the htmx response headers set so far.
+// Once the request context is done, e.g. because the client went away, nothing is written and an error wrapping
+// the context error is returned, test it with errors.Is(err, context.Canceled).
+// A response committed with a status without body, like 204 No Content, gets http.ErrBodyNotAllowed.
+// The data goes through the fragment filters of the htmx instance first, see WithFragmentFilters, a failing filter
+// aborts the write and its error is returned. With filters n is len(data) once the filtered data is written.
+// Like any http.ResponseWriter the handler is not safe for concurrent writes: concurrent first writes commit the
+// status and headers once, but their bodies may interleave, write from a single goroutine.
 func (h *Handler) Write(data []byte) (n int, err error) {
-	return h.w.Write(data)
+	if err := h.r.Context().Err(); err != nil {
+		return 0, fmt.Errorf("htmx: request done before writing the response: %w", err)
+	}
+
+	if h.bodyLimit.isExceeded() {
+		return len(data), nil
+	}
+
+	out := data
+	for _, f := range h.filters {
+		if out, err = f.Filter(out); err != nil {
+			return 0, fmt.Errorf("htmx: fragment filter failed: %w", err)
+		}
+	}
+
+	if h.compression && !h.isCommitted() && h.w.Header().Get("Content-Type") == "" {
+		h.w.Header().Set("Content-Type", http.DetectContentType(out))
+	}
+
+	h.commit(h.status)
+
+	if h.status == http.StatusNoContent || h.status == http.StatusNotModified {
+		return 0, http.ErrBodyNotAllowed
+	}
+
+	if h.gz != nil {
+		n, err = h.gz.Write(out)
+	} else {
+		n, err = h.w.Write(out)
+	}
+
+	if err == nil && len(h.filters) > 0 {
+		n = len(data)
+	}
+
+	return n, err
 }
 
 // WriteHTML is a helper that writes HTML data to the connection.
@@ -69,6 +434,24 @@ func (h *Handler) WriteJSON(data any) (n int, err error) {
 	return h.Write(payload)
 }
 
+// JSON responds with the status and the JSON encoding of v, for routes serving a JSON API next to htmx fragments.
+// The htmx response headers staged so far are dropped, they are meaningless to an API client.
+// An encoding error is returned before anything is written, so the caller can still respond with an error.
+func (h *Handler) JSON(status int, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	h.discardStaged()
+
+	h.w.Header().Set("Content-Type", "application/json")
+	h.WriteHeader(status)
+
+	_, err = h.Write(payload)
+	return err
+}
+
 // JustWrite writes the data to the connection as part of an HTTP reply.
 func (h *Handler) JustWrite(data []byte) {
 	_, err := h.Write(data)
@@ -133,122 +516,827 @@ func (h *Handler) MustWriteJSON(data any) {
 	}
 }
 
+// discardStaged drops the htmx response headers and triggered events staged so far.
+func (h *Handler) discardStaged() {
+	for k := range h.response.headers {
+		delete(h.response.headers, k)
+	}
+	h.triggers = nil
+}
+
+// Status sets the status committed by the first Write or Flush instead of 200 OK, e.g. 422 with a validation
+// error fragment, and returns the handler for chaining with the other setters.
+// An explicit status wins: WriteHeader and the helpers committing the response themselves, like StopPolling,
+// Error or AuthRedirect, ignore it. Redirect, Refresh and Location only set headers and keep the status.
+// Avoid 3xx codes with htmx headers, the browser follows the redirect before htmx sees them.
+// Calling Status after the response is committed has no effect.
+func (h *Handler) Status(code int) *Handler {
+	if h.isCommitted() {
+		h.log.Warn("htmx: status set after the response was committed", "status", code)
+		return h
+	}
+
+	h.status = code
+	return h
+}
+
 // WriteHeader sets the HTTP response header with the provided status code.
+// It commits the htmx response headers set so far, setters called afterward have no effect.
+// The Handler defers the status and the htmx headers until the first Write, WriteHeader or Flush, setters can be
+// called in any order until then. Calling WriteHeader on the underlying http.ResponseWriter bypasses this.
 func (h *Handler) WriteHeader(code int) {
+	h.commit(code)
+}
+
+// Commit sends the status set with Status, 200 OK by default, and the staged htmx response headers without writing
+// a body, for header only responses like a Redirect. Only the first Commit, Write, WriteHeader or Flush commits the
+// response, calling Commit afterward is a no-op and later writes go to the body as usual.
+// Once the request context is done nothing is sent and an error wrapping the context error is returned.
+func (h *Handler) Commit() error {
+	if h.isCommitted() {
+		return nil
+	}
+
+	if err := h.r.Context().Err(); err != nil {
+		return fmt.Errorf("htmx: request done before committing the response: %w", err)
+	}
+
+	h.commit(h.status)
+	return nil
+}
+
+// commit copies the staged htmx response headers to the underlying writer and sends the status code.
+// Only the first call has any effect, concurrent first calls wait for it to finish, so the status and the headers
+// are written once. The observer and the OnCommit callbacks run afterward, outside the guard, so they may write.
+func (h *Handler) commit(code int) {
+	if h.state.done.Load() || h.bodyLimit.isExceeded() {
+		return
+	}
+
+	first := false
+	h.state.once.Do(func() {
+		first = true
+		h.writeStatus(code)
+		h.state.done.Store(true)
+	})
+	if !first {
+		return
+	}
+
+	h.observer.OnResponse(code)
+
+	for _, fn := range h.onCommit {
+		fn(code)
+	}
+}
+
+// isCommitted returns true once the status and headers have been sent, or the connection was hijacked.
+func (h *Handler) isCommitted() bool {
+	return h.state.done.Load()
+}
+
+// writeStatus sends the status code with the staged htmx response headers, see commit.
+func (h *Handler) writeStatus(code int) {
+	h.status = code
+
+	navigation := h.Navigation()
+
+	header := h.w.Header()
+	for k, v := range h.response.headers {
+		if len(v) == 0 || v[0] == "" {
+			h.log.Debug("htmx: dropping empty header", "header", k)
+			continue
+		}
+		if dropped, ok := navigationKeys[k]; ok && dropped != navigation {
+			h.log.Warn("htmx: conflicting navigation headers, dropping the one with lower precedence",
+				"kept", navigation.String(), "dropped", dropped.String())
+			continue
+		}
+		header[k] = v
+	}
+
+	h.startCompression(code)
 	h.w.WriteHeader(code)
 }
 
-// StopPolling sets the response status to 286, which will stop htmx from polling
+// OnCommit registers fn to run once the response is committed, by the first Write, WriteHeader, Commit, Flush or by
+// Release, with the status sent, e.g. for metrics or audit logging. Callbacks run in registration order, exactly
+// once, right after the status line is written and before any body, so a failing body write does not skip them.
+// fn registered after the commit never runs, nor does it for a hijacked connection. The handler must not be
+// released from fn, the body is still to be written.
+func (h *Handler) OnCommit(fn func(status int)) {
+	if h.isCommitted() {
+		h.log.Warn("htmx: commit callback registered after the response was committed")
+		return
+	}
+
+	h.onCommit = append(h.onCommit, fn)
+}
+
+// navigationPrecedence lists the headers navigating away from the page, the first one set wins
+var navigationPrecedence = []HxResponseKey{HXRedirect, HXRefresh, HXLocation}
+
+// navigationKeys maps the canonical header keys of navigationPrecedence to their htmx name
+var navigationKeys = map[string]HxResponseKey{
+	http.CanonicalHeaderKey(HXRedirect.String()): HXRedirect,
+	http.CanonicalHeaderKey(HXRefresh.String()):  HXRefresh,
+	http.CanonicalHeaderKey(HXLocation.String()): HXLocation,
+}
+
+// Navigation returns the navigation header the response is committed with. When several are set only the one with
+// the highest precedence is sent: HX-Redirect, then HX-Refresh, then HX-Location. The others are dropped and
+// logged. It returns an empty key when none is set.
+func (h *Handler) Navigation() HxResponseKey {
+	for _, k := range navigationPrecedence {
+		if h.response.Get(k) != "" {
+			return k
+		}
+	}
+
+	return ""
+}
+
+// FlushError commits the response, with the staged htmx response headers and 200 OK unless a status was set,
+// sends any buffered compressed data and flushes the underlying writer through http.ResponseController, which
+// finds a http.Flusher behind writers exposing Unwrap. It returns ErrFlushNotSupported when no writer in the
+// chain can flush. http.ResponseController calls it for the handler, so streaming works however it is wrapped.
+func (h *Handler) FlushError() error {
+	if h.bodyLimit.isExceeded() {
+		return nil
+	}
+
+	h.commit(h.status)
+
+	if h.gz != nil {
+		if err := h.gz.Flush(); err != nil {
+			return fmt.Errorf("htmx: unable to flush the compressed body: %w", err)
+		}
+	}
+
+	if !canFlush(h.w) {
+		return fmt.Errorf("%w: %T", ErrFlushNotSupported, h.w)
+	}
+
+	return http.NewResponseController(h.w).Flush()
+}
+
+// Flush is FlushError for the http.Flusher interface, errors are logged. A writer unable to flush is not an error,
+// the response is committed all the same.
+func (h *Handler) Flush() {
+	if err := h.FlushError(); err != nil && !errors.Is(err, ErrFlushNotSupported) {
+		h.log.Error("htmx: unable to flush the response", "error", err)
+	}
+}
+
+// Hijack lets the caller take over the connection when the underlying writer is a http.Hijacker.
+// The staged htmx response headers are never sent then.
+func (h *Handler) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	h.state.once.Do(func() {
+		h.state.done.Store(true)
+	})
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying response writer, which http.ResponseController relies on.
+// Writing the status or body to it directly bypasses the deferred commit of the htmx response headers.
+func (h *Handler) Unwrap() http.ResponseWriter {
+	return h.w
+}
+
+// SwapInto writes the body into the element matching target using the given swap style, in one call.
+// It returns ErrInvalidSwapStyle without writing anything when the style is unknown. Out of band fragments can
+// still be written afterward, see OOB.
+func (h *Handler) SwapInto(target string, style SwapStyle, body []byte) (n int, err error) {
+	if !style.Valid() {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSwapStyle, style)
+	}
+
+	h.ReTarget(target).ReSwapWithObject(NewSwap().Style(style))
+
+	return h.Write(body)
+}
+
+// RequireConfirm reports whether the request carries the confirmation marker, the confirm request header of the htmx
+// instance set to true, see WithConfirmHeader. Without it the request is answered with 409 Conflict and the event,
+// DefaultConfirmEvent when empty, is triggered with the message, method and url of the request as detail, the
+// handler must then return without performing the action. The page is expected to ask the user and send the
+// request again with the marker, for example:
+//
+//	document.body.addEventListener("confirmRequired", (evt) => {
+//		if (confirm(evt.detail.message)) {
+//			htmx.ajax(evt.detail.method, evt.detail.url, {source: evt.target, headers: {"X-Confirmed": "true"}})
+//		}
+//	})
+//
+// By default htmx swaps nothing for a 409, the triggered event is processed anyway.
+func (h *Handler) RequireConfirm(message, event string) bool {
+	if HxStrToBool(h.r.Header.Get(h.confirmHeader)) {
+		return true
+	}
+
+	if event == "" {
+		event = DefaultConfirmEvent
+	}
+
+	h.TriggerWithObject(NewTrigger().AddEventDetail(event, map[string]string{
+		"message": message,
+		"method":  h.r.Method,
+		"url":     h.r.URL.RequestURI(),
+	}))
+	h.WriteHeader(http.StatusConflict)
+
+	return false
+}
+
+// Error responds with a consistent htmx error: the response is retargeted to the error container of the
+// htmx instance, see WithErrorTarget, swapped with innerHTML, an error notification is triggered and the escaped
+// userMessage is written as fragment. err is only logged, it never reaches the client.
+// htmx does not swap 4xx and 5xx responses unless configured to, see https://htmx.org/docs/#response-handling.
+func (h *Handler) Error(status int, userMessage string, err error) {
+	h.log.Error("htmx: request failed", "status", status, "path", h.r.URL.Path, "error", err)
+
+	h.ReTarget(h.errorTarget).ReSwapStyle(SwapInnerHTML)
+	h.TriggerError(userMessage)
+
+	h.w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	h.WriteHeader(status)
+	h.JustWriteString(template.HTMLEscapeString(userMessage))
+}
+
+// StopPolling sets the response status to 286 and commits the response. htmx treats this status specially,
+// an element polling with hx-trigger="every ..." stops polling. It can be called with or without writing a body.
+// https://htmx.org/docs/#polling
 func (h *Handler) StopPolling() {
 	h.WriteHeader(StatusStopPolling)
 }
 
+// TriggerOnly commits a side effect only response: nothing is swapped, HX-Reswap is none, and the events are
+// triggered with HX-Trigger, e.g. to show an "item added" toast. The response is 200 OK with an empty body,
+// the handler should return afterward.
+func (h *Handler) TriggerOnly(events ...string) {
+	t := NewTrigger()
+	for _, event := range events {
+		t.AddEvent(event)
+	}
+
+	h.ReSwapStyle(SwapNone)
+	h.TriggerWithObject(t)
+	h.WriteHeader(http.StatusOK)
+}
+
+// WillSwap returns false when the response staged so far swaps nothing: HX-Reswap is none, or the status is 204 No
+// Content, and no out of band fragment is waiting in OOB. A handler can then skip rendering a body nobody sees.
+// It only reflects what the server set, a client overriding the swap, e.g. in a htmx:beforeSwap listener, is not
+// accounted for.
+func (h *Handler) WillSwap() bool {
+	if h.oob != nil && len(h.oob.fragments) > 0 {
+		return true
+	}
+
+	if h.status == http.StatusNoContent {
+		return false
+	}
+
+	style, _, _ := strings.Cut(strings.TrimSpace(h.response.Get(HXReswap)), " ")
+	return SwapStyle(style) != SwapNone
+}
+
+// NoContent commits a 204 No Content response triggering the events with HX-Trigger, for actions firing events
+// without swapping anything. htmx never swaps a 204 response but still processes its headers, so the events fire
+// like with TriggerOnly, which answers 200 OK with HX-Reswap none for setups expecting a swap decision instead.
+// No body can be written afterward, the handler should return.
+func (h *Handler) NoContent(events ...string) {
+	if len(events) > 0 {
+		t := NewTrigger()
+		for _, event := range events {
+			t.AddEvent(event)
+		}
+
+		h.TriggerWithObject(t)
+	}
+
+	h.WriteHeader(http.StatusNoContent)
+}
+
+// EarlyHints sends a 103 Early Hints response with the given Link header values before the main response, so
+// the browser starts loading htmx and the stylesheets of a full page early, e.g.
+// `</static/htmx.min.js>; rel=preload; as=script`. The links stay in the headers of the main response.
+// Hints are only sent on full page requests, htmx requests already have their assets, and before the response
+// is committed. They need the writer of the net/http server, which supports 1xx responses over HTTP/1.1 and
+// HTTP/2 since Go 1.19, a proxy in front of it may drop them. Most wrappers would take 103 as the final status,
+// so it is only sent when the writer of the handler is the server writer or unwraps to it: a wrapper in between
+// must implement Unwrap, see http.ResponseController. Other writers, and HTTP/1.0 clients, are skipped with a
+// debug log entry.
+func (h *Handler) EarlyHints(links ...string) {
+	if len(links) == 0 || h.isCommitted() || h.IsHxRequest() || !h.r.ProtoAtLeast(1, 1) {
+		return
+	}
+
+	w := innermostWriter(h.w)
+	if !isServerWriter(w) {
+		h.log.Debug("htmx: the response writer does not support early hints", "writer", fmt.Sprintf("%T", w))
+		return
+	}
+
+	header := w.Header()
+	for _, link := range links {
+		header.Add("Link", link)
+	}
+
+	w.WriteHeader(http.StatusEarlyHints)
+}
+
+// innermostWriter unwraps w down to the writer of the server, see http.ResponseController. It stops at the last
+// writer of the chain that is not nil.
+func innermostWriter(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+
+		next := u.Unwrap()
+		if next == nil {
+			return w
+		}
+
+		w = next
+	}
+}
+
+// isServerWriter returns true when w is the response writer of the net/http server, HTTP/1.1 or HTTP/2.
+func isServerWriter(w http.ResponseWriter) bool {
+	if w == nil {
+		return false
+	}
+
+	t := reflect.TypeOf(w)
+	return t.Kind() == reflect.Pointer && t.Elem().PkgPath() == "net/http"
+}
+
+// Vary adds the request headers the response depends on to the Vary response header.
+// Headers already listed, also by an upstream handler, are not repeated.
+func (h *Handler) Vary(headers ...string) {
+	header := h.w.Header()
+	for _, v := range headers {
+		addVary(header, v)
+	}
+}
+
+// VaryFromRequest adds the htmx request headers read so far through the accessors of the handler, like Target or
+// TriggerID, to the Vary response header. Call it once the response is decided, so edge caches key the response on
+// exactly the headers that shaped it instead of a blanket Vary: HX-Request.
+func (h *Handler) VaryFromRequest() {
+	for _, k := range h.reads {
+		h.Vary(h.requestPrefix + k.String())
+	}
+}
+
+// MarkFragment sets the fragment header, DefaultFragmentHeader unless WithFragmentHeader changed it, to "true",
+// telling edge layers the response is a fragment rather than a page. Render and the RenderNamed helpers call it on
+// their partial and boosted branches. The header only labels the response: a cache storing responses by URL still
+// needs Vary: HX-Request, see VaryFromRequest, to keep the fragment and the page apart.
+// It has no effect once the response is committed.
+func (h *Handler) MarkFragment() {
+	if h.fragmentHeader == "" {
+		return
+	}
+	if h.isCommitted() {
+		h.log.Debug("htmx: fragment header set after the response was committed", "header", h.fragmentHeader)
+		return
+	}
+
+	h.w.Header().Set(h.fragmentHeader, "true")
+}
+
+// markRead records that the response depends on the given request headers, see VaryFromRequest.
+func (h *Handler) markRead(keys ...HxRequestHeaderKey) {
+	for _, k := range keys {
+		if !containsKey(h.reads, k) {
+			h.reads = append(h.reads, k)
+		}
+	}
+}
+
+func containsKey(keys []HxRequestHeaderKey, k HxRequestHeaderKey) bool {
+	for _, v := range keys {
+		if v == k {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Header returns the header map that will be sent by WriteHeader
 func (h *Handler) Header() http.Header {
 	return h.w.Header()
 }
 
 type LocationInput struct {
-	Source  string                 `json:"source"`  // source - the source element of the request
-	Event   string                 `json:"event"`   //event - an event that "triggered" the request
-	Handler string                 `json:"handler"` //handler - a callback that will handle the response HTML
-	Target  string                 `json:"target"`  //target - the target to swap the response into
-	Swap    string                 `json:"swap"`    //swap - how the response will be swapped in relative to the target
-	Values  map[string]interface{} `json:"values"`  //values - values to submit with the request
-	Header  map[string]interface{} `json:"headers"` //headers - headers to submit with the request
+	Path    string                 `json:"path,omitempty"`    // path - the url to load the response from
+	Source  string                 `json:"source,omitempty"`  // source - the source element of the request
+	Event   string                 `json:"event,omitempty"`   // event - an event that "triggered" the request
+	Handler string                 `json:"handler,omitempty"` // handler - a callback that will handle the response HTML
+	Target  string                 `json:"target,omitempty"`  // target - the target to swap the response into
+	Swap    string                 `json:"swap,omitempty"`    // swap - how the response will be swapped in relative to the target
+	Values  map[string]interface{} `json:"values,omitempty"`  // values - values to submit with the request
+	Header  map[string]interface{} `json:"headers,omitempty"` // headers - headers to submit with the request
+	Select  string                 `json:"select,omitempty"`  // select - allows you to select the content you want swapped from a response
+}
 
+// isPathOnly returns true when only the path is set, htmx then accepts the plain url as header value
+func (li *LocationInput) isPathOnly() bool {
+	return li.Path != "" && li.Source == "" && li.Event == "" && li.Handler == "" && li.Target == "" &&
+		li.Swap == "" && len(li.Values) == 0 && len(li.Header) == 0 && li.Select == ""
 }
 
-// Location can be used to trigger a client side redirection without reloading the whole page
+// Location can be used to trigger a client side redirection without reloading the whole page.
+// Empty fields are omitted, when only the path is set the header holds the plain path.
 // https://htmx.org/headers/hx-location/
 func (h *Handler) Location(li *LocationInput) error {
+	if li.isPathOnly() {
+		h.LocationPath(li.Path)
+		return nil
+	}
+
 	payload, err := json.Marshal(li)
 	if err != nil {
+		h.log.Error("htmx: unable to encode location", "error", err)
 		return err
 	}
 
-	h.response.Set(HXLocation, string(payload))
+	h.setHeader(HXLocation, string(payload))
 	return nil
 }
 
+// LocationPath triggers a client side redirection to the given path without reloading the whole page.
+// https://htmx.org/headers/hx-location/
+func (h *Handler) LocationPath(path string) {
+	h.setHeader(HXLocation, path)
+}
+
 // PushURL pushes a new url into the history stack.
+// An empty url is ignored, use PushURLFalse to prevent the history from being updated.
+// https://htmx.org/headers/hx-push-url/
+func (h *Handler) PushURL(val string) *Handler {
+	if val == "" {
+		h.log.Warn("htmx: ignoring empty push url")
+		return h
+	}
+
+	h.setHeader(HXPushUrl, val)
+	return h
+}
+
+// PushURLUnlessBoosted pushes the url into the history stack, like PushURL, except for boosted requests, as
+// reported by HX-Boosted, for which it does nothing: htmx already pushes the URL of a boosted link or form, a second
+// push would add a duplicate history entry. Targeted htmx requests and plain requests get the header.
+func (h *Handler) PushURLUnlessBoosted(url string) {
+	if h.Boosted() {
+		h.log.Debug("htmx: not pushing the url of a boosted request", "url", url)
+		return
+	}
+
+	h.PushURL(url)
+}
+
+// PushURLMerge pushes base, or the current URL when base is empty, with its query updated by params into the
+// history stack, e.g. to change the page of a filtered list while keeping the filters. An empty value removes
+// the parameter. The query is re-encoded with its parameters sorted by key.
+// An invalid base, or a missing current URL, is logged and no header is set.
+func (h *Handler) PushURLMerge(base string, params map[string]string) {
+	var (
+		u   *url.URL
+		err error
+	)
+	if base == "" {
+		u, err = h.CurrentURLParsed()
+		if err == nil && u == nil {
+			err = fmt.Errorf("htmx: no %s header to merge with", HxRequestHeaderCurrentURL)
+		}
+	} else {
+		u, err = url.Parse(base)
+	}
+	if err != nil {
+		h.log.Warn("htmx: ignoring push url", "error", err)
+		return
+	}
+
+	merged := *u
+	query := merged.Query()
+	for k, v := range params {
+		if v == "" {
+			query.Del(k)
+			continue
+		}
+
+		query.Set(k, v)
+	}
+	merged.RawQuery = query.Encode()
+
+	h.PushURL(merged.String())
+}
+
+// PushURLFalse prevents the browser history from being updated.
 // https://htmx.org/headers/hx-push-url/
-func (h *Handler) PushURL(val string) {
-	h.response.Set(HXPushUrl, val)
+func (h *Handler) PushURLFalse() *Handler {
+	h.setHeader(HXPushUrl, HxBoolToStr(false))
+	return h
 }
 
-// Redirect can be used to do a client-side redirect to a new location
+// Redirect can be used to do a client-side redirect to a new location, htmx performs a full page load.
+// The redirect takes precedence over Refresh and Location, when several are set only HX-Redirect is sent, see Navigation.
+// htmx only reads the header on a response it processes itself, so do not combine it with a 3xx status.
+// It works without a body: h.Redirect(url) followed by return sends 200 OK with the header once the handler is
+// released, which the Middleware does, call Commit to send it sooner.
+// https://htmx.org/reference/#response_headers
 func (h *Handler) Redirect(val string) {
-	h.response.Set(HXRedirect, val)
+	h.setHeader(HXRedirect, val)
+}
+
+// AuthRedirect sends the user to the login page: htmx requests get a HX-Redirect, so the whole page navigates
+// instead of a login form being swapped into the target, other requests get a 302 Found.
+// The response is committed, the handler should return afterward.
+func (h *Handler) AuthRedirect(loginURL string) {
+	if !h.IsHxRequest() {
+		http.Redirect(h, h.r, loginURL, http.StatusFound)
+		return
+	}
+
+	h.Redirect(loginURL)
+	h.WriteHeader(http.StatusOK)
 }
 
-// Refresh if set to true the client side will do a full refresh of the page
+// Refresh if set to true the client side will do a full refresh of the page.
+// htmx ignores a false value, so Refresh(false) removes the header instead of sending it.
+// A refresh takes precedence over Location and gives way to Redirect, see Navigation.
+// Like Redirect it needs no body, the header is committed at the latest when the handler is released.
 func (h *Handler) Refresh(val bool) {
-	h.response.Set(HXRefresh, HxBoolToStr(val))
+	if !val {
+		h.response.Del(HXRefresh)
+		return
+	}
+
+	h.setHeader(HXRefresh, HxBoolToStr(val))
 }
 
 // ReplaceURL allows you to replace the current URL in the browser location history.
+// An empty url, or one containing a line break, is ignored.
 // https://htmx.org/headers/hx-replace-url/
-func (h *Handler) ReplaceURL(val string) {
-	h.response.Set(HXReplaceUrl, val)
+func (h *Handler) ReplaceURL(val string) *Handler {
+	if val == "" {
+		h.log.Warn("htmx: ignoring empty replace url")
+		return h
+	}
+
+	h.setHeader(HXReplaceUrl, val)
+	return h
+}
+
+// ReplaceURLFalse prevents the current URL in the browser location history from being replaced.
+// https://htmx.org/headers/hx-replace-url/
+func (h *Handler) ReplaceURLFalse() *Handler {
+	h.setHeader(HXReplaceUrl, HxBoolToStr(false))
+	return h
 }
 
 // ReSwap allows you to specify how the response will be swapped. See hx-swap for possible values
 // https://htmx.org/attributes/hx-swap/
-func (h *Handler) ReSwap(val string) {
-	h.response.Set(HXReswap, val)
+func (h *Handler) ReSwap(val string) *Handler {
+	h.setHeader(HXReswap, val)
+	return h
 }
 
-// ReSwapWithObject allows you to specify how the response will be swapped. See hx-swap for possible values
+// ReSwapStyle allows you to specify the style the response will be swapped with, an invalid style is ignored.
 // https://htmx.org/attributes/hx-swap/
-func (h *Handler) ReSwapWithObject(s *Swap) {
-	h.ReSwap(s.String())
+func (h *Handler) ReSwapStyle(style SwapStyle) *Handler {
+	if !style.Valid() {
+		h.log.Warn("htmx: ignoring invalid swap style", "style", style.String())
+		return h
+	}
+
+	return h.ReSwap(style.String())
 }
 
-// ReTarget a CSS selector that updates the target of the content update to a different element on the page
-func (h *Handler) ReTarget(val string) {
-	h.response.Set(HXRetarget, val)
+// ReSwapTiming sets HX-Reswap to the swap and settle timings only, e.g. "swap:100ms settle:50ms", to adjust the
+// timing of an animation. A value without style makes htmx use its default swap style, htmx.config.defaultSwapStyle,
+// the hx-swap attribute of the element is replaced as a whole by HX-Reswap; use ReSwapWithObject with the style
+// for elements swapping otherwise. Negative durations are logged and no header is set.
+func (h *Handler) ReSwapTiming(swap, settle time.Duration) *Handler {
+	if swap < 0 || settle < 0 {
+		h.log.Warn("htmx: ignoring negative swap timing", "swap", swap.String(), "settle", settle.String())
+		return h
+	}
+
+	return h.ReSwap(newTiming(TimingSwap, 0, swap).String() + " " + newTiming(TimingSettle, 0, settle).String())
 }
 
-// ReSelect a CSS selector that allows you to choose which part of the response is used to be swapped in. Overrides an existing hx-select on the triggering element
-func (h *Handler) ReSelect(val string) {
-	h.response.Set(HXReselect, val)
+// ReSwapWithObject allows you to specify how the response will be swapped. See hx-swap for possible values.
+// Swap and settle timings the Swap does not set explicitly are taken from the defaults of the htmx instance,
+// see WithSwapDuration and WithSettleDelay, a bare innerHTML is sent as "innerHTML swap:0ms settle:20ms".
+// Use Swap.WithoutDefaults to send only what the Swap sets.
+// https://htmx.org/attributes/hx-swap/
+func (h *Handler) ReSwapWithObject(s *Swap) *Handler {
+	return h.ReSwap(s.renderWithDefaults(h.swapDuration, h.settleDelay))
+}
+
+// ReTarget a CSS selector that updates the target of the content update to a different element on the page.
+// An empty selector, or one containing a line break, is ignored.
+func (h *Handler) ReTarget(val string) *Handler {
+	h.setSelector(HXRetarget, val)
+	return h
+}
+
+// ReSelect a CSS selector that allows you to choose which part of the response is used to be swapped in.
+// It is evaluated in place of an existing hx-select on the triggering element, not on top of it,
+// and can be combined with ReTarget and ReSwap. An empty selector, or one containing a line break, is ignored.
+func (h *Handler) ReSelect(val string) *Handler {
+	h.setSelector(HXReselect, val)
+	return h
 }
 
 // Trigger triggers events as soon as the response is received.
 // https://htmx.org/headers/hx-trigger/
 func (h *Handler) Trigger(val string) {
-	h.response.Set(HXTrigger, val)
+	h.setHeader(HXTrigger, val)
 }
 
-// TriggerWithObject triggers events as soon as the response is received.
+// TriggerWithObject triggers events as soon as the response is received, or at the default timing of the htmx
+// instance, see WithDefaultTriggerTiming. The notification triggers go through it as well.
+// Repeated calls merge their events into a single header, see mergeTrigger.
 // https://htmx.org/headers/hx-trigger/
 func (h *Handler) TriggerWithObject(t *Trigger) {
-	h.Trigger(t.String())
+	val, ok := h.encodeTrigger(h.triggerTiming, t)
+	if !ok {
+		return
+	}
+
+	h.setHeader(h.triggerTiming, val)
+}
+
+// TriggerNowWithObject triggers events as soon as the response is received, whatever the default timing.
+// https://htmx.org/headers/hx-trigger/
+func (h *Handler) TriggerNowWithObject(t *Trigger) {
+	val, ok := h.encodeTrigger(HXTrigger, t)
+	if !ok {
+		return
+	}
+
+	h.Trigger(val)
 }
 
 // TriggerAfterSettle trigger events after the settling step.
 // https://htmx.org/headers/hx-trigger/
 func (h *Handler) TriggerAfterSettle(val string) {
-	h.response.Set(HXTriggerAfterSettle, val)
+	h.setHeader(HXTriggerAfterSettle, val)
 }
 
-// TriggerAfterSettleWithObject trigger events after the settling step.
+// TriggerAfterSettleWithObject trigger events after the settling step, repeated calls are merged.
 // https://htmx.org/headers/hx-trigger/
 func (h *Handler) TriggerAfterSettleWithObject(t *Trigger) {
-	h.TriggerAfterSettle(t.String())
+	val, ok := h.encodeTrigger(HXTriggerAfterSettle, t)
+	if !ok {
+		return
+	}
+
+	h.TriggerAfterSettle(val)
 }
 
 // TriggerAfterSwap trigger events after the swap step.
 // https://htmx.org/headers/hx-trigger/
 func (h *Handler) TriggerAfterSwap(val string) {
-	h.response.Set(HXTriggerAfterSwap, val)
+	h.setHeader(HXTriggerAfterSwap, val)
 }
 
-// TriggerAfterSwapWithObject trigger events after the swap step.
+// TriggerAfterSwapWithObject trigger events after the swap step, repeated calls are merged.
 // https://htmx.org/headers/hx-trigger/
 func (h *Handler) TriggerAfterSwapWithObject(t *Trigger) {
-	h.TriggerAfterSwap(t.String())
+	val, ok := h.encodeTrigger(HXTriggerAfterSwap, t)
+	if !ok {
+		return
+	}
+
+	h.TriggerAfterSwap(val)
+}
+
+// mergeTrigger adds the events of t to the ones already sent with the header, so several call sites, like a
+// middleware and the handler, can trigger events on the same response. Details of an event triggered twice are
+// replaced by the last call, which is logged. A value set with Trigger, TriggerAfterSettle or TriggerAfterSwap
+// is not merged, the next object replaces it.
+func (h *Handler) mergeTrigger(k HxResponseKey, t *Trigger) *Trigger {
+	if h.triggers == nil {
+		h.triggers = make(map[HxResponseKey]*Trigger)
+	}
+
+	merged, ok := h.triggers[k]
+	if !ok {
+		merged = NewTrigger()
+		h.triggers[k] = merged
+	}
+
+	for _, event := range merged.merge(t) {
+		h.log.Warn("htmx: replacing the details of a triggered event", "header", k.String(), "event", event)
+	}
+
+	return merged
+}
+
+// encodeTrigger merges t into the events of the header and encodes them with the trigger encoder of the htmx
+// instance. It reports false, after logging the error, when the events cannot be encoded.
+// A value above the trigger size limit is logged and handed to the overflow fallback, see WithTriggerSizeLimit.
+func (h *Handler) encodeTrigger(k HxResponseKey, t *Trigger) (string, bool) {
+	val, err := h.encodeEvents(h.mergeTrigger(k, t))
+	if err != nil {
+		h.log.Warn("htmx: encoding triggered events", "header", k.String(), "error", err)
+		return "", false
+	}
+
+	if h.triggerLimit > 0 && len(val) > h.triggerLimit {
+		h.log.Warn("htmx: triggered events exceed the header size limit, proxies may drop or truncate the header",
+			"header", k.String(), "size", len(val), "limit", h.triggerLimit)
+
+		if h.triggerOverflow != nil {
+			val = h.triggerOverflow(h, k, val)
+		}
+	}
+
+	return val, true
+}
+
+// encodeEvents returns the encoding of the Trigger set, with the events in the order they were added when the
+// htmx instance has WithOrderedTriggers.
+func (h *Handler) encodeEvents(t *Trigger) (string, error) {
+	if h.orderedTriggers {
+		return t.encodeOrdered(h.triggerEncoder)
+	}
+
+	return t.encode(h.triggerEncoder)
+}
+
+// setHeader stages a response header after making sure the value cannot inject other headers.
+// It reports whether the header was set.
+func (h *Handler) setHeader(k HxResponseKey, val string) bool {
+	if h.headerless {
+		h.log.Debug("htmx: dropping header, the response writer has no header map", "header", k.String())
+		return false
+	}
+
+	val, err := sanitizeHeaderValue(val)
+	if err != nil {
+		h.log.Warn(err.Error(), "header", k.String())
+		return false
+	}
+
+	h.response.Set(k, val)
+	return true
+}
+
+// setSelector stages a response header holding a CSS selector, empty selectors are ignored.
+func (h *Handler) setSelector(k HxResponseKey, val string) bool {
+	if strings.TrimSpace(val) == "" {
+		h.log.Warn("htmx: ignoring empty selector", "header", k.String())
+		return false
+	}
+
+	return h.setHeader(k, val)
+}
+
+// Release commits the staged htmx response headers and the status set with Status when nothing was written, so a
+// handler calling only setters, like Redirect, still sends them. It then finishes a compressed body, see Close,
+// and returns the handler to the pool of the htmx instance, see WithHandlerPool. Nothing is kept from the request,
+// the handler must not be used afterward. The Middleware releases its handlers once next returns.
+func (h *Handler) Release() {
+	if !h.isCommitted() && h.w != nil && (len(h.response.headers) > 0 || h.status != http.StatusOK) {
+		h.commit(h.status)
+	}
+
+	if err := h.Close(); err != nil {
+		h.log.Error("htmx: unable to finish the compressed body", "error", err)
+	}
+
+	if h.pool == nil {
+		return
+	}
+
+	pool, response, state := h.pool, h.response, h.state
+	for k := range response.headers {
+		delete(response.headers, k)
+	}
+
+	*state = commitState{}
+	*h = Handler{response: response, state: state}
+	pool.Put(h)
+}
+
+// Context returns the context of the request, see Handler.WithTimeout for a handler with a deadline.
+func (h *Handler) Context() context.Context {
+	return h.r.Context()
 }
 
 // Request returns the HxHeaders from the request
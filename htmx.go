@@ -4,11 +4,12 @@
 package htmx
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
-
-	"go.uber.org/zap"
 )
 
 var (
@@ -16,63 +17,378 @@ var (
 	DefaultSettleDelay  = time.Duration(20 * time.Millisecond)
 
 	DefaultNotificationKey = "showMessage"
+
+	// DefaultErrorTarget is the selector of the element error responses are retargeted to.
+	DefaultErrorTarget = "#error"
+
+	// DefaultErrorMessage is the message shown to the user by the default error handler of HTMX.HandlerFunc.
+	DefaultErrorMessage = "Something went wrong, please try again."
+
+	// DefaultEventDetailHeader is the request header Handler.TriggerDetail decodes.
+	DefaultEventDetailHeader = "X-Event-Detail"
+
+	// DefaultClientInfoHeader is the request header Handler.ClientInfo parses. htmx does not send one itself, set
+	// it with hx-headers or a htmx:configRequest listener, e.g. to "2.0.3; ext=sse,ws".
+	DefaultClientInfoHeader = "X-Htmx-Version"
+
+	// DefaultConfirmHeader is the request header marking a request as confirmed, see Handler.RequireConfirm.
+	DefaultConfirmHeader = "X-Confirmed"
+
+	// DefaultConfirmEvent is the event Handler.RequireConfirm triggers when no event is given.
+	DefaultConfirmEvent = "confirmRequired"
+
+	// DefaultPreloadHeader is the request header the htmx preload extension marks speculative requests with,
+	// see Handler.IsPreload.
+	DefaultPreloadHeader = "HX-Preloaded"
+
+	// DefaultIdempotencyHeader is the request header carrying the idempotency key, see HTMX.Idempotent.
+	DefaultIdempotencyHeader = "Idempotency-Key"
+
+	// DefaultIdempotencyTTL is how long HTMX.Idempotent replays a response.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// DefaultSSETriggerEvent is the name of the server sent event carrying triggered events, see
+	// SSEWriter.SendTrigger. The client listens for it and dispatches each event with htmx.trigger.
+	DefaultSSETriggerEvent = "htmx-trigger"
+
+	// DefaultTriggerSizeLimit is the size, in bytes, above which a trigger header is reported, proxies and
+	// servers commonly limit headers to 8KB. See WithTriggerSizeLimit.
+	DefaultTriggerSizeLimit = 8 << 10
+
+	// DefaultFormMaxBytes is the size, in bytes, of the request body Handler.FormValue and Handler.DecodeForm
+	// parse at most, like the limit of http.Request.ParseForm. See WithFormMaxBytes.
+	DefaultFormMaxBytes int64 = 10 << 20
+
+	// DefaultFragmentHeader is the response header Handler.MarkFragment sets to "true" on partial responses,
+	// see WithFragmentHeader.
+	DefaultFragmentHeader = "X-Fragment"
+
+	// RenderModeHeader is the response header the render helpers set to the chosen Mode, see WithRenderModeHeader.
+	RenderModeHeader = "X-Htmx-Render-Mode"
+)
+
+const (
+	// ModeFull is a regular browser request or a history restore, render the whole page.
+	ModeFull Mode = iota
+	// ModeBoosted is a boosted navigation, htmx replaces the body.
+	ModeBoosted
+	// ModePartial is a htmx request targeting an element, render the fragment only.
+	ModePartial
 )
 
 type (
+	// Mode tells how much of the page a request expects, see RenderMode.
+	Mode int
+
 	HTMX struct {
-		log *zap.Logger
+		log             Logger
+		observer        Observer
+		clock           Clock
+		swapDuration    time.Duration
+		settleDelay     time.Duration
+		notificationKey string
+		errorTarget     string
+		detailHeader    string
+		confirmHeader   string
+		pool            *sync.Pool
+		skipPaths       []string
+		currentURLPath  bool
+		requestPrefix   string
+		errorHandler    func(*Handler, error)
+		recoverView     func(*Handler, any)
+		clientHeader    string
+		formMaxBytes    int64
+		fragmentHeader  string
+		partialNoStore  bool
+		oobCollision    OOBCollision
+		renderModeDebug bool
+		orderedTriggers bool
+		filters         []FragmentFilter
+		compression     bool
+		triggerEncoder  func(any) ([]byte, error)
+		triggerTiming   HxResponseKey
+		preloadHeader   string
+		sseTriggerEvent string
+		triggerLimit    int
+		triggerOverflow TriggerOverflow
+
+		notificationSchema func(level, message string) any
+
+		fragmentCache       FragmentCache
+		fragmentCacheHxOnly bool
+		boostedFragments    bool
+
+		idempotencyHeader string
+		idempotencyTTL    time.Duration
 	}
 )
 
 // New returns a new htmx instance.
-func New() *HTMX {
-	// prepares a basic logger
-	log, _ := zap.NewProduction(zap.WithCaller(false))
+// Without options the instance takes its settings from the package defaults,
+// and nothing is logged until a logger is configured with WithLogger or SetLog.
+func New(opts ...Option) *HTMX {
+	h := &HTMX{
+		log:             noopLogger{},
+		observer:        noopObserver{},
+		clock:           realClock{},
+		errorHandler:    defaultErrorHandler,
+		recoverView:     defaultRecoverView,
+		swapDuration:    DefaultSwapDuration,
+		settleDelay:     DefaultSettleDelay,
+		notificationKey: DefaultNotificationKey,
+		errorTarget:     DefaultErrorTarget,
+		detailHeader:    DefaultEventDetailHeader,
+		confirmHeader:   DefaultConfirmHeader,
+		clientHeader:    DefaultClientInfoHeader,
+		formMaxBytes:    DefaultFormMaxBytes,
+		fragmentHeader:  DefaultFragmentHeader,
+		triggerEncoder:  json.Marshal,
+		triggerTiming:   HXTrigger,
+		preloadHeader:   DefaultPreloadHeader,
+		sseTriggerEvent: DefaultSSETriggerEvent,
+		triggerLimit:    DefaultTriggerSizeLimit,
 
-	return &HTMX{
-		log: log,
+		notificationSchema: defaultNotificationSchema,
+
+		idempotencyHeader: DefaultIdempotencyHeader,
+		idempotencyTTL:    DefaultIdempotencyTTL,
+
+		fragmentCache: NewLRUFragmentCache(DefaultFragmentCacheSize),
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
-// SetLog sets the logger for the htmx instance.
-func (h *HTMX) SetLog(log *zap.Logger) {
+// SetLog sets the logger for the htmx instance, nil disables logging.
+// Use zaplog.New to keep logging through an existing zap logger.
+func (h *HTMX) SetLog(log Logger) {
+	if log == nil {
+		log = noopLogger{}
+	}
+
 	h.log = log
 }
 
-// NewHandler returns a new htmx handler.
+// NewHandler returns a new htmx handler and reports the request to the observer, see WithObserver.
+// With WithHandlerPool the handler is taken from the pool, call Release once the request is done.
 func (h *HTMX) NewHandler(w http.ResponseWriter, r *http.Request) *Handler {
-	return &Handler{
-		w:        w,
-		r:        r,
-		request:  h.HxHeader(r),
-		response: h.HxResponseHeader(w.Header()),
-		log:      h.log,
+	handler := h.newHandler(w, r)
+	h.observer.OnRequest(RenderModeFromHeader(handler.request))
+
+	return handler
+}
+
+// newHandler returns a new htmx handler without reporting the request to the observer, for the middlewares
+// answering a request on their own, which the Middleware or the final handler already reports.
+func (h *HTMX) newHandler(w http.ResponseWriter, r *http.Request) *Handler {
+	handler := &Handler{}
+	if h.pool != nil {
+		handler = h.pool.Get().(*Handler)
+	}
+
+	headerless := w != nil && w.Header() == nil
+	if headerless {
+		h.log.Warn("htmx: the response writer has no header map, response headers are dropped",
+			"writer", fmt.Sprintf("%T", w))
+		w = &headerlessWriter{ResponseWriter: w, header: http.Header{}}
+	}
+
+	response := handler.response
+	if response == nil {
+		response = h.HxResponseHeader(http.Header{})
+	}
+
+	state := handler.state
+	if state == nil {
+		state = &commitState{}
+	}
+
+	*handler = Handler{
+		w:               w,
+		r:               r,
+		request:         h.HxHeader(r),
+		response:        response,
+		state:           state,
+		status:          http.StatusOK,
+		log:             h.log,
+		observer:        h.observer,
+		pool:            h.pool,
+		swapDuration:    h.swapDuration,
+		settleDelay:     h.settleDelay,
+		notificationKey: h.notificationKey,
+		errorTarget:     h.errorTarget,
+		detailHeader:    h.detailHeader,
+		confirmHeader:   h.confirmHeader,
+		compression:     h.compression,
+		triggerEncoder:  h.triggerEncoder,
+		triggerTiming:   h.triggerTiming,
+		preload:         HxStrToBool(r.Header.Get(h.preloadHeader)),
+		sseTriggerEvent: h.sseTriggerEvent,
+		headerless:      headerless,
+		triggerLimit:    h.triggerLimit,
+		triggerOverflow: h.triggerOverflow,
+		requestPrefix:   h.requestPrefix,
+		clientHeader:    h.clientHeader,
+		formMaxBytes:    h.formMaxBytes,
+		fragmentHeader:  h.fragmentHeader,
+		partialNoStore:  h.partialNoStore,
+		oobCollision:    h.oobCollision,
+		renderModeDebug: h.renderModeDebug,
+		orderedTriggers: h.orderedTriggers,
+		filters:         h.filters,
+
+		notificationSchema: h.notificationSchema,
+
+		fragmentCache:       h.fragmentCache,
+		fragmentCacheHxOnly: h.fragmentCacheHxOnly,
+		boostedFragments:    h.boostedFragments,
 	}
+
+	return handler
+}
+
+// HandlerFunc adapts fn to a http.HandlerFunc for any router: it gets the Handler stored by the Middleware, or a
+// new one released once fn returns, and passes a returned error to the error handler of the instance, see
+// WithErrorHandler. By default that is Error with 500 Internal Server Error and DefaultErrorMessage, or only a
+// log entry when fn had already committed the response.
+func (h *HTMX) HandlerFunc(fn func(*Handler) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := FromContext(r.Context())
+		if !ok {
+			handler = h.NewHandler(w, r)
+			defer handler.Release()
+		}
+
+		if err := fn(handler); err != nil {
+			h.errorHandler(handler, err)
+		}
+	}
+}
+
+// defaultErrorHandler is the error handler of HTMX.HandlerFunc without WithErrorHandler.
+func defaultErrorHandler(h *Handler, err error) {
+	if h.isCommitted() {
+		h.log.Error("htmx: request failed after the response was written", "path", h.r.URL.Path, "error", err)
+		return
+	}
+
+	h.Error(http.StatusInternalServerError, DefaultErrorMessage, err)
+}
+
+// headerlessWriter gives a response writer whose Header method returns nil a header map of its own, so the
+// handler does not panic. Nothing set in it reaches the client.
+type headerlessWriter struct {
+	http.ResponseWriter
+	header http.Header
+}
+
+func (w *headerlessWriter) Header() http.Header {
+	return w.header
+}
+
+// Unwrap returns the underlying response writer, see http.ResponseController.
+func (w *headerlessWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
+// canonical keys of the headers read by the package level predicates, the header map is indexed with them
+// directly instead of canonicalizing the key on every call
+var (
+	canonicalHxRequest        = http.CanonicalHeaderKey(HxRequestHeaderRequest.String())
+	canonicalHxBoosted        = http.CanonicalHeaderKey(HxRequestHeaderBoosted.String())
+	canonicalHxHistoryRestore = http.CanonicalHeaderKey(HxRequestHeaderHistoryRestoreRequest.String())
+)
+
 // IsHxRequest returns true if the request is a htmx request.
 func IsHxRequest(r *http.Request) bool {
-	return HxStrToBool(r.Header.Get(HxRequestHeaderRequest.String()))
+	return HxStrToBool(headerValue(r.Header, canonicalHxRequest))
 }
 
 // IsHxBoosted returns true if the request is a htmx request and the request is boosted
 func IsHxBoosted(r *http.Request) bool {
-	return HxStrToBool(r.Header.Get(HxRequestHeaderBoosted.String()))
+	return HxStrToBool(headerValue(r.Header, canonicalHxBoosted))
 }
 
 // IsHxHistoryRestoreRequest returns true if the request is a htmx request and the request is a history restore request
 func IsHxHistoryRestoreRequest(r *http.Request) bool {
-	return HxStrToBool(r.Header.Get(HxRequestHeaderHistoryRestoreRequest.String()))
+	return HxStrToBool(headerValue(r.Header, canonicalHxHistoryRestore))
+}
+
+// headerValue returns the first value of the header with the canonical key, like http.Header.Get.
+func headerValue(header http.Header, key string) string {
+	if v := header[key]; len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
 }
 
 // RenderPartial returns true if the request is an HTMX request that is either boosted or a hx request,
 // provided it is not a history restore request.
 func RenderPartial(r *http.Request) bool {
-	return (IsHxRequest(r) || IsHxBoosted(r)) && !IsHxHistoryRestoreRequest(r)
+	return RenderMode(r) != ModeFull
+}
+
+// RenderPartialFromHeader is RenderPartial for already parsed request headers,
+// it saves the header lookups when the parsed headers are at hand.
+func RenderPartialFromHeader(h HxRequestHeader) bool {
+	return RenderModeFromHeader(h) != ModeFull
+}
+
+// RenderMode returns how much of the page the request expects.
+// The precedence is history restore (ModeFull) > boosted (ModeBoosted) > hx request (ModePartial) > ModeFull.
+// A request without HX-Request is not a htmx request and gets ModeFull before the other headers are read, which
+// keeps plain browser requests to a single header lookup.
+func RenderMode(r *http.Request) Mode {
+	if !IsHxRequest(r) {
+		return ModeFull
+	}
+
+	return renderMode(true, IsHxBoosted(r), IsHxHistoryRestoreRequest(r))
+}
+
+// RenderModeFromHeader is RenderMode for already parsed request headers.
+func RenderModeFromHeader(h HxRequestHeader) Mode {
+	return renderMode(h.HxRequest, h.HxBoosted, h.HxHistoryRestoreRequest)
 }
 
-// HxStrToBool converts a string to a boolean value.
+func renderMode(request, boosted, historyRestore bool) Mode {
+	switch {
+	case !request, historyRestore:
+		return ModeFull
+	case boosted:
+		return ModeBoosted
+	}
+
+	return ModePartial
+}
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeFull:
+		return "full"
+	case ModeBoosted:
+		return "boosted"
+	case ModePartial:
+		return "partial"
+	}
+
+	return "unknown"
+}
+
+// HxStrToBool converts a header value to a boolean value.
+// "true" in any case and "1" are true, everything else, including "", "false" and "0", is false.
 func HxStrToBool(str string) bool {
+	return str == "1" || HxStrToBoolStrict(str)
+}
+
+// HxStrToBoolStrict converts a header value to a boolean value, only "true" in any case is true.
+func HxStrToBoolStrict(str string) bool {
 	return strings.EqualFold(str, "true")
 }
 
@@ -1,10 +1,19 @@
 package htmx
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -90,7 +99,7 @@ func TestNew(t *testing.T) {
 	handler.TriggerAfterSettleWithObject(NewTrigger().AddEvent(triggerAfterSettle))
 	handler.TriggerAfterSwapWithObject(NewTrigger().AddEvent(triggerAfterSwap))
 
-	equal(t, reswapWithObject.String(), handler.ResponseHeader(HXReswap))
+	equal(t, "innerHTML scroll:top swap:0ms settle:1s", handler.ResponseHeader(HXReswap))
 
 	head := handler.Header()
 	equal(t, "true", head.Get("Hx-Request"))
@@ -111,6 +120,169 @@ func TestNew(t *testing.T) {
 	equalInt(t, 2, i)
 }
 
+func TestRequestAccessors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(HxRequestHeaderRequest.String(), "true")
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://current-url.com/page")
+	r.Header.Set(HxRequestHeaderPrompt.String(), "yes")
+	r.Header.Set(HxRequestHeaderTarget.String(), "list")
+	r.Header.Set(HxRequestHeaderTrigger.String(), "save-button")
+	r.Header.Set(HxRequestHeaderTriggerName.String(), "save")
+
+	handler := New().NewHandler(httptest.NewRecorder(), r)
+
+	equalBool(t, true, handler.RequestHeader())
+	equalBool(t, false, handler.Boosted())
+	equalBool(t, false, handler.HistoryRestoreRequest())
+	equal(t, "http://current-url.com/page", handler.CurrentURL())
+	equal(t, "yes", handler.Prompt())
+	equal(t, "list", handler.Target())
+	equal(t, "save-button", handler.TriggerID())
+	equal(t, "save", handler.TriggerName())
+
+	empty := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	equalBool(t, false, empty.RequestHeader())
+	equal(t, "", empty.CurrentURL())
+	equal(t, "", empty.TriggerID())
+	equal(t, "", empty.TriggerName())
+}
+
+func TestTriggeringElement(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		id      string
+		trigger string
+	}{
+		{"id only", map[string]string{"HX-Trigger": "save-button"}, "save-button", ""},
+		{"name only", map[string]string{"HX-Trigger-Name": " save "}, "", "save"},
+		{"both", map[string]string{"HX-Trigger": "save-button", "HX-Trigger-Name": "save"}, "save-button", "save"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			id, name := New().NewHandler(httptest.NewRecorder(), r).TriggeringElement()
+			equal(t, tt.id, id)
+			equal(t, tt.trigger, name)
+		})
+	}
+}
+
+func TestTriggerDetail(t *testing.T) {
+	type detail struct {
+		ID int `json:"id"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(DefaultEventDetailHeader, `{"id":7}`)
+
+	var d detail
+	if err := New().NewHandler(httptest.NewRecorder(), r).TriggerDetail(&d); err != nil {
+		t.Fatal(err)
+	}
+	equalInt(t, 7, d.ID)
+
+	r = httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-Detail", `{"id":`)
+	handler := New(WithEventDetailHeader("X-Detail")).NewHandler(httptest.NewRecorder(), r)
+	err := handler.TriggerDetail(&d)
+	equalBool(t, true, err != nil && !errors.Is(err, ErrNoEventDetail))
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	equalBool(t, true, errors.Is(handler.TriggerDetail(&d), ErrNoEventDetail))
+}
+
+func TestTriggerJSON(t *testing.T) {
+	var v struct {
+		ID   string `json:"id"`
+		Page int    `json:"page"`
+	}
+
+	handler := func(trigger string) *Handler {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(HxRequestHeaderTrigger.String(), trigger)
+		return New().NewHandler(httptest.NewRecorder(), r)
+	}
+
+	h := handler(`{"id":"more","page":3}`)
+	if err := h.TriggerJSON(&v); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "more", v.ID)
+	equalInt(t, 3, v.Page)
+	equal(t, `{"id":"more","page":3}`, h.TriggerID())
+
+	for _, trigger := range []string{"load-more", "{weird-id", ""} {
+		equalBool(t, true, errors.Is(handler(trigger).TriggerJSON(&v), ErrTriggerNotJSON))
+	}
+	equal(t, "load-more", handler("load-more").TriggerID())
+
+	err := handler(`{"page":"three"}`).TriggerJSON(&v)
+	equalBool(t, true, err != nil && !errors.Is(err, ErrTriggerNotJSON))
+}
+
+func TestCurrentURLParsed(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://example.com/pokemon?page=2&type=fire")
+	handler := New().NewHandler(httptest.NewRecorder(), r)
+
+	u, err := handler.CurrentURLParsed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "/pokemon", u.Path)
+	equal(t, "fire", u.Query().Get("type"))
+
+	again, _ := handler.CurrentURLParsed()
+	equalBool(t, true, u == again)
+
+	u, err = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)).CurrentURLParsed()
+	equalBool(t, true, u == nil && err == nil)
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://example.com/%zz")
+	u, err = New().NewHandler(httptest.NewRecorder(), r).CurrentURLParsed()
+	equalBool(t, true, u == nil && err != nil)
+}
+
+func TestPromptValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		prompt  string
+		present bool
+	}{
+		{"present", map[string]string{"HX-Prompt": "yes"}, "yes", true},
+		{"encoded", map[string]string{"HX-Prompt": "Caf%C3%A9%20au%20lait"}, "Café au lait", true},
+		{"empty", map[string]string{"HX-Prompt": ""}, "", true},
+		{"absent", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			handler := New().NewHandler(httptest.NewRecorder(), r)
+
+			prompt, ok := handler.PromptValue()
+			equal(t, tt.prompt, prompt)
+			equalBool(t, tt.present, ok)
+
+			prompt, err := handler.RequirePrompt()
+			equal(t, tt.prompt, prompt)
+			equalBool(t, !tt.present, errors.Is(err, ErrPromptMissing))
+		})
+	}
+}
+
 func TestNoRouter(t *testing.T) {
 	h := New()
 
@@ -148,11 +320,11 @@ func TestNoRouter(t *testing.T) {
 		t.Error("an error occurred when reading the response")
 	}
 
-	j, _ := json.Marshal(location)
-	equal(t, string(j), resp.Header.Get(HXLocation.String()))
+	// HX-Redirect takes precedence over HX-Refresh and HX-Location
+	equal(t, "", resp.Header.Get(HXLocation.String()))
 	equal(t, pushURL, resp.Header.Get(HXPushUrl.String()))
 	equal(t, redirect, resp.Header.Get(HXRedirect.String()))
-	equal(t, HxBoolToStr(refresh), resp.Header.Get(HXRefresh.String()))
+	equal(t, "", resp.Header.Get(HXRefresh.String()))
 	equal(t, replaceURL, resp.Header.Get(HXReplaceUrl.String()))
 	equal(t, reSwap, resp.Header.Get(HXReswap.String()))
 	equal(t, reTarget, resp.Header.Get(HXRetarget.String()))
@@ -162,6 +334,512 @@ func TestNoRouter(t *testing.T) {
 	equalInt(t, http.StatusAccepted, resp.StatusCode)
 }
 
+// countingWriter counts the WriteHeader calls reaching the recorder.
+type countingWriter struct {
+	*httptest.ResponseRecorder
+	writeHeaders int
+}
+
+func (w *countingWriter) WriteHeader(code int) {
+	w.writeHeaders++
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+func TestWritePlainBody(t *testing.T) {
+	w := &countingWriter{ResponseRecorder: httptest.NewRecorder()}
+	handler := New().NewHandler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReSwap("")
+	_, _ = handler.WriteString("<p>one</p>")
+	_, _ = handler.WriteString("<p>two</p>")
+	handler.Release()
+
+	equalInt(t, http.StatusOK, w.Code)
+	equalInt(t, 1, w.writeHeaders)
+	equal(t, "<p>one</p><p>two</p>", w.Body.String())
+	for k := range w.Header() {
+		if strings.HasPrefix(strings.ToLower(k), "hx-") {
+			t.Errorf("unexpected header %s", k)
+		}
+	}
+}
+
+func TestWriteCommitsHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReTarget(reTarget)
+	equal(t, "", rec.Header().Get(HXRetarget.String()))
+
+	handler.JustWriteString("hello")
+	handler.ReSelect(reSelect)
+	handler.JustWriteString(" world")
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equalInt(t, 1, len(rec.Header().Values(HXRetarget.String())))
+	equal(t, reTarget, rec.Header().Get(HXRetarget.String()))
+	equal(t, "", rec.Header().Get(HXReselect.String()))
+	equal(t, "hello world", rec.Body.String())
+}
+
+func TestFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReTarget("#main")
+	handler.Flush()
+	handler.ReTarget("#ignored")
+
+	equalBool(t, true, rec.Flushed)
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "#main", rec.Header().Get(HXRetarget.String()))
+
+	_, _, err := handler.Hijack()
+	equalBool(t, true, errors.Is(err, http.ErrNotSupported))
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err := http.NewResponseController(handler).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	equalBool(t, true, rec.Flushed)
+	equalBool(t, true, handler.Unwrap() == http.ResponseWriter(rec))
+}
+
+func TestJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReTarget("#main")
+	handler.TriggerWithObject(NewTrigger().AddEvent("loaded"))
+
+	if err := handler.JSON(http.StatusCreated, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	equalInt(t, http.StatusCreated, rec.Code)
+	equal(t, "application/json", rec.Header().Get("Content-Type"))
+	equal(t, `{"id":1}`, rec.Body.String())
+	equal(t, "", rec.Header().Get(HXRetarget.String()))
+	equal(t, "", rec.Header().Get(HXTrigger.String()))
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	equalBool(t, true, handler.JSON(http.StatusOK, make(chan int)) != nil)
+	equalBool(t, false, handler.isCommitted())
+}
+
+func TestVary(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Vary", "Accept-Encoding")
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.Vary("HX-Request", "HX-Target")
+	handler.Vary("hx-request")
+	_ = handler.CurrentURL()
+	_ = handler.CurrentURL()
+
+	equal(t, "Accept-Encoding,HX-Request,HX-Target,HX-Current-URL", strings.Join(rec.Header().Values("Vary"), ","))
+}
+
+func TestVaryFromRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HxRequestHeaderTarget.String(), "list")
+	req.Header.Set(HxRequestHeaderTrigger.String(), "load")
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, req)
+
+	equal(t, "list", handler.Target())
+	handler.Target()
+	handler.VaryFromRequest()
+
+	equal(t, "HX-Target", strings.Join(rec.Header().Values("Vary"), ","))
+}
+
+// nilHeaderWriter is a minimal response writer whose Header method returns nil.
+type nilHeaderWriter struct {
+	status int
+	body   strings.Builder
+}
+
+func (w *nilHeaderWriter) Header() http.Header         { return nil }
+func (w *nilHeaderWriter) WriteHeader(code int)        { w.status = code }
+func (w *nilHeaderWriter) Write(p []byte) (int, error) { return w.body.Write(p) }
+
+func TestNilHeaderMap(t *testing.T) {
+	log := &recordLogger{}
+	w := &nilHeaderWriter{}
+	handler := New(WithLogger(log)).NewHandler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReTarget("#list")
+	handler.PushURLFalse()
+	handler.ReplaceURLFalse()
+	handler.Trigger("saved")
+	handler.Vary("HX-Target")
+	handler.Header().Set("Content-Type", "text/html")
+	_, _ = handler.WriteString("ok")
+
+	equalInt(t, http.StatusOK, w.status)
+	equal(t, "ok", w.body.String())
+	equal(t, "", handler.ResponseHeader(HXRetarget))
+	equal(t, "", handler.ResponseHeader(HXPushUrl))
+	equal(t, "", handler.ResponseHeader(HXReplaceUrl))
+	equal(t, "warn: htmx: the response writer has no header map, response headers are dropped", log.entries[0])
+	equal(t, "debug: htmx: dropping header, the response writer has no header map", log.entries[1])
+}
+
+func TestError(t *testing.T) {
+	log := &recordLogger{}
+	rec := httptest.NewRecorder()
+	handler := New(WithLogger(log), WithErrorTarget("#flash")).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/save", nil))
+
+	handler.Error(http.StatusInternalServerError, "Saving <failed>", errors.New("connection refused"))
+
+	equalInt(t, http.StatusInternalServerError, rec.Code)
+	equal(t, "#flash", rec.Header().Get(HXRetarget.String()))
+	equal(t, "innerHTML", rec.Header().Get(HXReswap.String()))
+	equal(t, `{"showMessage":{"level":"error","message":"Saving \u003cfailed\u003e"}}`, rec.Header().Get(HXTrigger.String()))
+	equal(t, "Saving &lt;failed&gt;", rec.Body.String())
+	equalInt(t, 1, len(log.entries))
+
+	rec = httptest.NewRecorder()
+	New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil)).Error(http.StatusBadRequest, "invalid", nil)
+	equal(t, DefaultErrorTarget, rec.Header().Get(HXRetarget.String()))
+}
+
+func TestSwapInto(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if _, err := handler.SwapInto("#errors", SwapBeforeEnd, []byte("<li>name is required</li>")); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = handler.OOB().Add("count", "1").Write(handler, "")
+
+	equal(t, "#errors", rec.Header().Get(HXRetarget.String()))
+	equal(t, "beforeend swap:0ms settle:20ms", rec.Header().Get(HXReswap.String()))
+	equal(t, `<li>name is required</li><div id="count" hx-swap-oob="true">1</div>`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	_, err := handler.SwapInto("#errors", "sideways", []byte("body"))
+	equalBool(t, true, errors.Is(err, ErrInvalidSwapStyle))
+	equal(t, "", rec.Body.String())
+}
+
+func TestRequireConfirm(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodDelete, "/items/1?force=1", nil))
+
+	equalBool(t, false, handler.RequireConfirm("Delete item 1?", ""))
+	equalInt(t, http.StatusConflict, rec.Code)
+	equal(t, `{"confirmRequired":{"message":"Delete item 1?","method":"DELETE","url":"/items/1?force=1"}}`, rec.Header().Get(HXTrigger.String()))
+
+	r := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+	r.Header.Set("X-Sure", "true")
+	rec = httptest.NewRecorder()
+	handler = New(WithConfirmHeader("X-Sure")).NewHandler(rec, r)
+
+	equalBool(t, true, handler.RequireConfirm("Delete item 1?", "askDelete"))
+	equal(t, "", rec.Header().Get(HXTrigger.String()))
+	equalBool(t, false, handler.isCommitted())
+}
+
+func TestPushURL(t *testing.T) {
+	log := &recordLogger{}
+	handler := New(WithLogger(log)).NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.PushURL(pushURL).PushURL("")
+	equal(t, pushURL, handler.ResponseHeader(HXPushUrl))
+	equalInt(t, 1, len(log.entries))
+
+	handler.PushURLFalse()
+	equal(t, "false", handler.ResponseHeader(HXPushUrl))
+}
+
+func TestReplaceURL(t *testing.T) {
+	log := &recordLogger{}
+	rec := httptest.NewRecorder()
+	handler := New(WithLogger(log)).NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReplaceURL(replaceURL).ReplaceURL("http://evil.com\r\nSet-Cookie: x=y")
+	handler.JustWriteString("")
+
+	equal(t, replaceURL, rec.Header().Get(HXReplaceUrl.String()))
+	equal(t, "", rec.Header().Get("Set-Cookie"))
+	equalInt(t, 1, len(log.entries))
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	handler.ReplaceURLFalse()
+	equal(t, "false", handler.ResponseHeader(HXReplaceUrl))
+}
+
+func TestRedirect(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	_ = handler.Location(location)
+	handler.Redirect(redirect)
+	handler.Redirect("http://evil.com\r\nSet-Cookie: x=y")
+	handler.WriteHeader(http.StatusOK)
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, redirect, rec.Header().Get(HXRedirect.String()))
+	equal(t, "", rec.Header().Get(HXLocation.String()))
+	equal(t, "", rec.Header().Get("Set-Cookie"))
+}
+
+func TestRedirectWithoutBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.Redirect(redirect)
+	handler.Release()
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, redirect, rec.Header().Get(HXRedirect.String()))
+	equalInt(t, 0, rec.Body.Len())
+}
+
+func TestCommit(t *testing.T) {
+	w := &countingWriter{ResponseRecorder: httptest.NewRecorder()}
+	handler := New().NewHandler(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.Redirect(redirect)
+	if err := handler.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	handler.Refresh(true)
+	handler.Release()
+
+	equalInt(t, http.StatusOK, w.Code)
+	equalInt(t, 1, w.writeHeaders)
+	equal(t, redirect, w.Header().Get(HXRedirect.String()))
+	equal(t, "", w.Header().Get(HXRefresh.String()))
+	equalInt(t, 0, w.Body.Len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx))
+	handler.Redirect(redirect)
+	equalBool(t, true, errors.Is(handler.Commit(), context.Canceled))
+	equal(t, "", rec.Header().Get(HXRedirect.String()))
+}
+
+func TestCommitConcurrent(t *testing.T) {
+	w := &countingWriter{ResponseRecorder: httptest.NewRecorder()}
+	handler := New().NewHandler(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	handler.Redirect(redirect)
+
+	var commits int32
+	handler.OnCommit(func(int) {
+		atomic.AddInt32(&commits, 1)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handler.Commit(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	equalInt(t, 1, w.writeHeaders)
+	equalInt(t, 1, int(atomic.LoadInt32(&commits)))
+	equal(t, redirect, w.Header().Get(HXRedirect.String()))
+}
+
+func TestOnCommit(t *testing.T) {
+	w := &countingWriter{ResponseRecorder: httptest.NewRecorder()}
+	handler := New().NewHandler(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	var calls []string
+	handler.OnCommit(func(status int) {
+		calls = append(calls, "first "+strconv.Itoa(status))
+	})
+	handler.OnCommit(func(status int) {
+		calls = append(calls, "second "+strconv.Itoa(status))
+	})
+
+	handler.Status(http.StatusCreated)
+	_, _ = handler.WriteString("<p>one</p>")
+	_, _ = handler.WriteString("<p>two</p>")
+	handler.OnCommit(func(status int) {
+		calls = append(calls, "late")
+	})
+	handler.Release()
+
+	equal(t, "first 201,second 201", strings.Join(calls, ","))
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	var status int
+	handler.OnCommit(func(code int) {
+		status = code
+	})
+	handler.Status(http.StatusNoContent)
+	_, err := handler.WriteString("<p>dropped</p>")
+	equalBool(t, true, errors.Is(err, http.ErrBodyNotAllowed))
+	equalInt(t, http.StatusNoContent, status)
+}
+
+func TestHeaderInjection(t *testing.T) {
+	const evil = "evil\r\nSet-Cookie: x=y"
+
+	log := &recordLogger{}
+	rec := httptest.NewRecorder()
+	handler := New(WithLogger(log)).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.Redirect(evil)
+	handler.ReTarget(evil)
+	handler.ReSelect(evil)
+	handler.ReSwap(evil)
+	handler.PushURL(evil)
+	handler.ReplaceURL(evil)
+	handler.LocationPath(evil)
+	handler.TriggerWithObject(NewTrigger().AddEvent(evil))
+	handler.TriggerAfterSettleWithObject(NewTrigger().AddEvent("ok").AddEvent(evil))
+	handler.TriggerAfterSwap(evil)
+	handler.JustWriteString("")
+
+	for k := range rec.Header() {
+		if k != "Content-Type" {
+			t.Errorf("unexpected header %s", k)
+		}
+	}
+	equalInt(t, 10, len(log.entries))
+}
+
+func TestNavigation(t *testing.T) {
+	tests := []struct {
+		name       string
+		set        func(h *Handler)
+		navigation HxResponseKey
+		warnings   int
+	}{
+		{"none", func(h *Handler) {}, "", 0},
+		{"location", func(h *Handler) { h.LocationPath("/next") }, HXLocation, 0},
+		{"refresh over location", func(h *Handler) { h.LocationPath("/next"); h.Refresh(true) }, HXRefresh, 1},
+		{"redirect over refresh", func(h *Handler) { h.Refresh(true); h.Redirect("/login") }, HXRedirect, 1},
+		{"redirect over all", func(h *Handler) { h.Refresh(true); h.Redirect("/login"); h.LocationPath("/next") }, HXRedirect, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &recordLogger{}
+			rec := httptest.NewRecorder()
+			handler := New(WithLogger(log)).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+			tt.set(handler)
+			equal(t, tt.navigation.String(), handler.Navigation().String())
+
+			handler.WriteHeader(http.StatusOK)
+
+			for _, k := range []HxResponseKey{HXRedirect, HXRefresh, HXLocation} {
+				equalBool(t, k == tt.navigation, rec.Header().Get(k.String()) != "")
+			}
+			equalInt(t, tt.warnings, len(log.entries))
+		})
+	}
+}
+
+func TestAuthRedirect(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/items", nil)
+	r.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+
+	New().NewHandler(rec, r).AuthRedirect("/login")
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "/login", rec.Header().Get(HXRedirect.String()))
+	equal(t, "", rec.Header().Get("Location"))
+
+	rec = httptest.NewRecorder()
+	New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/items", nil)).AuthRedirect("/login")
+
+	equalInt(t, http.StatusFound, rec.Code)
+	equal(t, "/login", rec.Header().Get("Location"))
+	equal(t, "", rec.Header().Get(HXRedirect.String()))
+}
+
+func TestRefresh(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/logout", nil))
+
+	handler.Refresh(true)
+	handler.JustWriteString("bye")
+
+	equal(t, "true", rec.Header().Get(HXRefresh.String()))
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/logout", nil))
+
+	handler.Refresh(true)
+	handler.Refresh(false)
+	handler.JustWriteString("bye")
+
+	if _, ok := rec.Header()[http.CanonicalHeaderKey(HXRefresh.String())]; ok {
+		t.Errorf("expected no %s header", HXRefresh)
+	}
+}
+
+func TestReTarget(t *testing.T) {
+	log := &recordLogger{}
+	rec := httptest.NewRecorder()
+	handler := New(WithLogger(log)).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.ReTarget("#main .panel").ReTarget("").ReTarget("#x\nSet-Cookie: x=y").ReSwap("outerHTML")
+	handler.JustWriteString("")
+
+	equal(t, "#main .panel", rec.Header().Get(HXRetarget.String()))
+	equal(t, "outerHTML", rec.Header().Get(HXReswap.String()))
+	equalInt(t, 2, len(log.entries))
+}
+
+func TestReSelect(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.ReTarget("#main").ReSelect("#content .items").ReSelect("\r\n").ReSwap("innerHTML")
+	handler.JustWriteString("")
+
+	equal(t, "#main", rec.Header().Get(HXRetarget.String()))
+	equal(t, "#content .items", rec.Header().Get(HXReselect.String()))
+	equal(t, "innerHTML", rec.Header().Get(HXReswap.String()))
+}
+
+func TestLocation(t *testing.T) {
+	handler := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	_ = handler.Location(&LocationInput{Path: "/test"})
+	equal(t, "/test", handler.ResponseHeader(HXLocation))
+
+	handler.LocationPath("/other")
+	equal(t, "/other", handler.ResponseHeader(HXLocation))
+
+	err := handler.Location(&LocationInput{Path: "/test", Target: "#main", Select: "#content", Values: map[string]interface{}{"page": 2}})
+	if err != nil {
+		t.Error(err)
+	}
+	equal(t, `{"path":"/test","target":"#main","values":{"page":2},"select":"#content"}`, handler.ResponseHeader(HXLocation))
+
+	err = handler.Location(&LocationInput{Path: "/test", Values: map[string]interface{}{"fn": func() {}}})
+	if err == nil {
+		t.Error("expected an encoding error")
+	}
+}
+
 func TestHxResponseKey_String(t *testing.T) {
 	equal(t, "HX-Location", HXLocation.String())
 	equal(t, "HX-Push-Url", HXPushUrl.String())
@@ -202,6 +880,216 @@ func TestStopPolling(t *testing.T) {
 	equalInt(t, StatusStopPolling, resp.StatusCode)
 }
 
+func TestStopPollingHelper(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/poll", nil))
+
+	handler.TriggerWithObject(NewTrigger().AddEvent("done"))
+	handler.StopPolling()
+
+	equalInt(t, StatusStopPolling, rec.Code)
+	equal(t, "done", rec.Header().Get(HXTrigger.String()))
+	equalInt(t, 0, rec.Body.Len())
+}
+
+func TestPushURLUnlessBoosted(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/pokemon", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Boosted", "true")
+
+	handler := New().NewHandler(httptest.NewRecorder(), r)
+	handler.PushURLUnlessBoosted("/pokemon?page=2")
+	equal(t, "", handler.ResponseHeader(HXPushUrl))
+
+	r = httptest.NewRequest(http.MethodGet, "/pokemon", nil)
+	r.Header.Set("HX-Request", "true")
+
+	handler = New().NewHandler(httptest.NewRecorder(), r)
+	handler.PushURLUnlessBoosted("/pokemon?page=2")
+	equal(t, "/pokemon?page=2", handler.ResponseHeader(HXPushUrl))
+}
+
+func TestPushURLMerge(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://example.com/users?sort=name&page=1&q=ash")
+	handler := New().NewHandler(httptest.NewRecorder(), r)
+
+	handler.PushURLMerge("/users?sort=name&page=1", map[string]string{"page": "2"})
+	equal(t, "/users?page=2&sort=name", handler.response.Get(HXPushUrl))
+
+	handler.PushURLMerge("", map[string]string{"page": "3", "q": ""})
+	equal(t, "http://example.com/users?page=3&sort=name", handler.response.Get(HXPushUrl))
+
+	current, _ := handler.CurrentURLParsed()
+	equal(t, "sort=name&page=1&q=ash", current.RawQuery)
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	handler.PushURLMerge("", map[string]string{"page": "2"})
+	equal(t, "", handler.response.Get(HXPushUrl))
+}
+
+func TestActiveNav(t *testing.T) {
+	nav := []string{"/", "/users", "/users/1", "/settings"}
+
+	r := httptest.NewRequest(http.MethodGet, "/users/1/edit", nil)
+	r.Header.Set(HxRequestHeaderBoosted.String(), "true")
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://example.com/users/1?tab=posts")
+	equal(t, "/users/1", New().NewHandler(httptest.NewRecorder(), r).ActiveNav(nav...))
+
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://example.com/users/2")
+	equal(t, "/users", New().NewHandler(httptest.NewRecorder(), r).ActiveNav(nav...))
+
+	r.Header.Set(HxRequestHeaderCurrentURL.String(), "http://example.com/usersettings")
+	equal(t, "/", New().NewHandler(httptest.NewRecorder(), r).ActiveNav(nav...))
+	equal(t, "", New().NewHandler(httptest.NewRecorder(), r).ActiveNav("/users", "/settings"))
+
+	r = httptest.NewRequest(http.MethodGet, "/settings/profile", nil)
+	equal(t, "/settings", New().NewHandler(httptest.NewRecorder(), r).ActiveNav(nav...))
+}
+
+func TestWriteCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	n, err := handler.WriteString("late")
+	equalInt(t, 0, n)
+	equalBool(t, true, errors.Is(err, context.Canceled))
+	equalInt(t, 0, rec.Body.Len())
+	equalBool(t, false, handler.isCommitted())
+}
+
+func TestStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/form", nil))
+
+	_, err := handler.Status(http.StatusUnprocessableEntity).ReTarget("#form").Write([]byte("invalid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equalInt(t, http.StatusUnprocessableEntity, rec.Code)
+	equal(t, "#form", rec.Header().Get(HXRetarget.String()))
+	equal(t, "invalid", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/poll", nil))
+	handler.Status(http.StatusAccepted).StopPolling()
+	handler.Status(http.StatusTeapot)
+
+	equalInt(t, StatusStopPolling, rec.Code)
+}
+
+func TestEarlyHints(t *testing.T) {
+	const link = "</static/htmx.min.js>; rel=preload; as=script"
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler := New().NewHandler(w, r)
+		handler.EarlyHints(link)
+		handler.JustWriteString("page")
+	}))
+	defer svr.Close()
+
+	request := func(hx bool) (hints []string) {
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				if code == http.StatusEarlyHints {
+					hints = append(hints, header.Values("Link")...)
+				}
+				return nil
+			},
+		}
+
+		req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, svr.URL, nil)
+		if hx {
+			req.Header.Set("HX-Request", "true")
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		equalInt(t, http.StatusOK, resp.StatusCode)
+		return hints
+	}
+
+	equal(t, link, strings.Join(request(false), ","))
+	equalInt(t, 0, len(request(true)))
+
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	handler.EarlyHints(link)
+	handler.JustWriteString("page")
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "", rec.Header().Get("Link"))
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(&nilUnwrapWriter{rec}, httptest.NewRequest(http.MethodGet, "/", nil))
+	handler.EarlyHints(link)
+	handler.JustWriteString("page")
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "", rec.Header().Get("Link"))
+}
+
+// nilUnwrapWriter is a response writer wrapper whose Unwrap returns nil.
+type nilUnwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w *nilUnwrapWriter) Unwrap() http.ResponseWriter { return nil }
+
+func TestTriggerOnly(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/cart", nil))
+
+	handler.TriggerOnly("itemAdded", "cartChanged")
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "none", rec.Header().Get(HXReswap.String()))
+	equal(t, "itemAdded, cartChanged", rec.Header().Get(HXTrigger.String()))
+	equalInt(t, 0, rec.Body.Len())
+}
+
+func TestWillSwap(t *testing.T) {
+	handler := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/cart", nil))
+	equalBool(t, true, handler.WillSwap())
+
+	handler.OOB().Add("cart-count", "3")
+	handler.ReSwapStyle(SwapNone)
+	equalBool(t, true, handler.WillSwap())
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/cart", nil))
+	handler.TriggerOnly("itemAdded")
+	equalBool(t, false, handler.WillSwap())
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/cart", nil))
+	handler.ReSwap("none swap:0ms")
+	equalBool(t, false, handler.WillSwap())
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/items/1", nil))
+	handler.NoContent("itemDeleted")
+	equalBool(t, false, handler.WillSwap())
+}
+
+func TestNoContent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodDelete, "/items/1", nil))
+
+	handler.NoContent("itemDeleted")
+	_, err := handler.WriteString("ignored")
+
+	equalInt(t, http.StatusNoContent, rec.Code)
+	equal(t, "itemDeleted", rec.Header().Get(HXTrigger.String()))
+	equalInt(t, 0, rec.Body.Len())
+	equalBool(t, true, errors.Is(err, http.ErrBodyNotAllowed))
+}
+
 func TestSwap(t *testing.T) {
 	h := New()
 
@@ -225,13 +1113,152 @@ func TestSwap(t *testing.T) {
 	}
 	defer resp.Body.Close()
 
-	equal(t, "innerHTML scroll:top settle:1s", resp.Header.Get(HXReswap.String()))
+	equal(t, "innerHTML scroll:top swap:0ms settle:1s", resp.Header.Get(HXReswap.String()))
+}
+
+func TestNewWithOptions(t *testing.T) {
+	log := &recordLogger{}
+	h := New(
+		WithLogger(log),
+		WithSwapDuration(100*time.Millisecond),
+		WithSettleDelay(50*time.Millisecond),
+		WithNotificationKey("toast"),
+	)
+
+	handler := h.NewHandler(dummyWriter{}, &http.Request{Header: http.Header{}})
+
+	if handler.log != log {
+		t.Errorf("expected the handler to use the configured logger")
+	}
+	equal(t, "100ms", handler.swapDuration.String())
+	equal(t, "50ms", handler.settleDelay.String())
+	equal(t, "toast", handler.notificationKey)
+
+	d := New().NewHandler(dummyWriter{}, &http.Request{Header: http.Header{}})
+	equal(t, DefaultSwapDuration.String(), d.swapDuration.String())
+	equal(t, DefaultSettleDelay.String(), d.settleDelay.String())
+	equal(t, DefaultNotificationKey, d.notificationKey)
+}
+
+func TestHandlerPool(t *testing.T) {
+	h := New(WithHandlerPool())
+
+	first := h.NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	first.ReTarget(reTarget)
+	first.TriggerWithObject(NewTrigger().AddEvent("saved"))
+	first.OOB().Add("sidebar", "<p>hi</p>")
+	first.JustWriteString("one")
+	first.Release()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HxRequestHeaderRequest.String(), "true")
+	rec := httptest.NewRecorder()
+
+	second := h.NewHandler(rec, r)
+	equalBool(t, true, second.IsHxRequest())
+	equalBool(t, false, second.isCommitted())
+	equal(t, "", second.ResponseHeader(HXRetarget))
+	equal(t, "", second.ResponseHeader(HXTrigger))
+	equalInt(t, 0, len(second.OOB().fragments))
+
+	second.JustWriteString("two")
+	equal(t, "", rec.Header().Get(HXRetarget.String()))
+	equal(t, "two", rec.Body.String())
+
+	// releasing without a pool is a no-op
+	unpooled := New().NewHandler(httptest.NewRecorder(), r)
+	unpooled.Release()
+	equalBool(t, true, unpooled.IsHxRequest())
+}
+
+func BenchmarkNewHandler(b *testing.B) {
+	h := New()
+	w, r := httptest.NewRecorder(), benchRequest()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handler := h.NewHandler(w, r)
+		handler.ReTarget(reTarget)
+		handler.Release()
+	}
+}
+
+func BenchmarkNewHandlerPooled(b *testing.B) {
+	h := New(WithHandlerPool())
+	w, r := httptest.NewRecorder(), benchRequest()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handler := h.NewHandler(w, r)
+		handler.ReTarget(reTarget)
+		handler.Release()
+	}
+}
+
+func TestSetLog(t *testing.T) {
+	h := New()
+	if _, ok := h.log.(noopLogger); !ok {
+		t.Errorf("expected the default logger to be a no-op logger, got %T", h.log)
+	}
+
+	for _, h := range []*HTMX{New(WithLogger(nil)), New(WithNoLog())} {
+		if h.log == nil {
+			t.Error("expected a logger")
+		}
+	}
+
+	log := &recordLogger{}
+	h.SetLog(log)
+
+	handler := h.NewHandler(dummyWriter{Writer: failingWriter{}}, &http.Request{Header: http.Header{}})
+	handler.JustWriteString("hi")
+
+	equalInt(t, 1, len(log.entries))
+}
+
+func TestNoLog(t *testing.T) {
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stderr = w
+
+	for _, h := range []*HTMX{New(), New(WithLogger(&recordLogger{}), WithNoLog()), New(WithLogger(nil))} {
+		handler := h.NewHandler(dummyWriter{Writer: failingWriter{}}, httptest.NewRequest(http.MethodGet, "/", nil))
+		handler.ReTarget("")
+		handler.Redirect("\n")
+		handler.JustWriteString("hi")
+	}
+
+	h := New()
+	h.SetLog(nil)
+	h.NewHandler(dummyWriter{Writer: failingWriter{}}, httptest.NewRequest(http.MethodGet, "/", nil)).JustWriteString("hi")
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	equal(t, "", string(out))
 }
 
 func TestHxStrToBool(t *testing.T) {
 	equalBool(t, true, HxStrToBool("true"))
 	equalBool(t, false, HxStrToBool("false"))
 	equalBool(t, false, HxStrToBool("not a bool"))
+
+	equalBool(t, false, HxStrToBool(""))
+	equalBool(t, true, HxStrToBool("True"))
+	equalBool(t, true, HxStrToBool("1"))
+	equalBool(t, false, HxStrToBool("0"))
+}
+
+func TestHxStrToBoolStrict(t *testing.T) {
+	equalBool(t, false, HxStrToBoolStrict(""))
+	equalBool(t, true, HxStrToBoolStrict("True"))
+	equalBool(t, false, HxStrToBoolStrict("1"))
+	equalBool(t, false, HxStrToBoolStrict("false"))
+	equalBool(t, false, HxStrToBoolStrict("0"))
 }
 
 func TestHxBoolToStr(t *testing.T) {
@@ -239,6 +1266,212 @@ func TestHxBoolToStr(t *testing.T) {
 	equal(t, "false", HxBoolToStr(false))
 }
 
+func TestRenderPartialFromHeader(t *testing.T) {
+	equalBool(t, false, RenderPartialFromHeader(HxRequestHeader{}))
+	equalBool(t, true, RenderPartialFromHeader(HxRequestHeader{HxRequest: true}))
+	equalBool(t, true, RenderPartialFromHeader(HxRequestHeader{HxRequest: true, HxBoosted: true}))
+	equalBool(t, false, RenderPartialFromHeader(HxRequestHeader{HxRequest: true, HxHistoryRestoreRequest: true}))
+	equalBool(t, false, RenderPartialFromHeader(HxRequestHeader{HxBoosted: true}))
+}
+
+func TestRenderMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		mode    Mode
+	}{
+		{"browser", nil, ModeFull},
+		{"partial", map[string]string{"HX-Request": "true"}, ModePartial},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, ModeBoosted},
+		{"history restore", map[string]string{"HX-Request": "true", "HX-Boosted": "true", "HX-History-Restore-Request": "true"}, ModeFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			equal(t, tt.mode.String(), RenderMode(r).String())
+			equal(t, tt.mode.String(), New().NewHandler(httptest.NewRecorder(), r).RenderMode().String())
+			equalBool(t, tt.mode != ModeFull, RenderPartial(r))
+		})
+	}
+}
+
+func TestStripHxHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HxRequestHeaderRequest.String(), "true")
+	req.Header.Set(HxRequestHeaderTarget.String(), "list")
+	req.Header.Set("Accept", "text/html")
+
+	copied := http.Header{}
+	CopyHxHeaders(copied, req.Header)
+	StripHxHeaders(req)
+
+	equalBool(t, false, IsHxRequest(req))
+	equal(t, "", req.Header.Get(HxRequestHeaderTarget.String()))
+	equal(t, "text/html", req.Header.Get("Accept"))
+	equal(t, "true", copied.Get(HxRequestHeaderRequest.String()))
+	equal(t, "list", copied.Get(HxRequestHeaderTarget.String()))
+	equal(t, "", copied.Get("Accept"))
+}
+
+func TestRequestHeadersMap(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HxRequestHeaderRequest.String(), "true")
+	req.Header.Set(HxRequestHeaderTarget.String(), "list")
+	req.Header.Set(HxRequestHeaderPrompt.String(), "")
+	req.Header.Set("Accept", "text/html")
+
+	headers := New().NewHandler(httptest.NewRecorder(), req).RequestHeadersMap()
+
+	equalInt(t, 2, len(headers))
+	equal(t, "true", headers["HX-Request"])
+	equal(t, "list", headers["HX-Target"])
+}
+
+func TestRequestHeaderPrefix(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-HX-Request", "true")
+	req.Header.Set("X-Forwarded-HX-Target", "list")
+	req.Header.Set("X-Forwarded-HX-Prompt", "yes")
+
+	h := New(WithRequestHeaderPrefix("X-Forwarded-"))
+	equalBool(t, true, h.IsHxRequest(req))
+	equalBool(t, false, IsHxRequest(req))
+	equalBool(t, false, New().IsHxRequest(req))
+
+	handler := h.NewHandler(httptest.NewRecorder(), req)
+	equalBool(t, true, handler.IsHxRequest())
+	equal(t, "list", handler.Target())
+	prompt, ok := handler.PromptValue()
+	equalBool(t, true, ok)
+	equal(t, "yes", prompt)
+	equal(t, "list", handler.RequestHeadersMap()["HX-Target"])
+}
+
+func TestClientInfo(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Client", "htmx/2.0.3; ext=sse, ws")
+
+	info := New(WithClientInfoHeader("X-Client")).NewHandler(httptest.NewRecorder(), req).ClientInfo()
+
+	equal(t, "2.0.3", info.Version)
+	equalInt(t, 2, info.Major)
+	equalInt(t, 0, info.Minor)
+	equalInt(t, 3, info.Patch)
+	equal(t, "sse,ws", strings.Join(info.Extensions, ","))
+	equalBool(t, true, info.AtLeast(1, 9))
+	equalBool(t, false, info.AtLeast(2, 1))
+	equalBool(t, true, info.HasExtension("ws"))
+
+	info = New().NewHandler(httptest.NewRecorder(), req).ClientInfo()
+	equal(t, "", info.Version)
+	equalBool(t, false, info.AtLeast(0, 0))
+
+	equalInt(t, 9, ParseClientInfo("v1.9").Minor)
+	equalInt(t, 0, ParseClientInfo("next").Major)
+}
+
+func TestTriggerMatches(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(HxRequestHeaderTrigger.String(), "save-button")
+	req.Header.Set(HxRequestHeaderTriggerName.String(), "action")
+	handler := New().NewHandler(httptest.NewRecorder(), req)
+
+	equalBool(t, true, handler.TriggerMatches("save-button", ""))
+	equalBool(t, true, handler.TriggerMatches("", "action"))
+	equalBool(t, true, handler.TriggerMatches("other", "action"))
+	equalBool(t, false, handler.TriggerMatches("Save-Button", "other"))
+	equalBool(t, false, handler.TriggerMatches("", ""))
+}
+
+func TestTriggerNameIs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(HxRequestHeaderTriggerName.String(), " publish ")
+	handler := New().NewHandler(httptest.NewRecorder(), req)
+
+	equalBool(t, true, handler.TriggerNameIs("save", "publish"))
+	equalBool(t, true, handler.TriggerNameIs("publish "))
+	equalBool(t, false, handler.TriggerNameIs("save", "Publish", "publish-all"))
+	equalBool(t, false, handler.TriggerNameIs())
+
+	handler = New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	equalBool(t, false, handler.TriggerNameIs("", "save"))
+}
+
+func TestHxRequestHeader_String(t *testing.T) {
+	equal(t, "{}", HxRequestHeader{}.String())
+	equal(t, "{HX-Request: true, HX-Target: main}", HxRequestHeader{HxRequest: true, HxTarget: "main"}.String())
+}
+
+// benchRequest returns a htmx request carrying 10 headers
+func benchRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Boosted", "true")
+	r.Header.Set("HX-Current-URL", "http://example.com/")
+	r.Header.Set("HX-Target", "main")
+	r.Header.Set("HX-Trigger", "link")
+	r.Header.Set("Accept", "text/html")
+	r.Header.Set("Accept-Language", "en")
+	r.Header.Set("Cookie", "session=1")
+	r.Header.Set("User-Agent", "bench")
+	r.Header.Set("Referer", "http://example.com/")
+
+	return r
+}
+
+func BenchmarkRenderPartial(b *testing.B) {
+	r := benchRequest()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = IsHxRequest(r)
+		_ = IsHxBoosted(r)
+		_ = RenderPartial(r)
+	}
+}
+
+// BenchmarkRenderPartialPlain compares reading the three headers of a plain browser request with RenderPartial,
+// which stops at the missing HX-Request.
+func BenchmarkRenderPartialPlain(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/html")
+	r.Header.Set("User-Agent", "bench")
+
+	b.Run("three lookups", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = renderMode(
+				HxStrToBool(r.Header.Get(HxRequestHeaderRequest.String())),
+				HxStrToBool(r.Header.Get(HxRequestHeaderBoosted.String())),
+				HxStrToBool(r.Header.Get(HxRequestHeaderHistoryRestoreRequest.String())),
+			) != ModeFull
+		}
+	})
+
+	b.Run("short circuit", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = RenderPartial(r)
+		}
+	})
+}
+
+func BenchmarkRenderPartialFromHeader(b *testing.B) {
+	header := HxRequestHeaderFromRequest(benchRequest())
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = header.HxRequest
+		_ = header.HxBoosted
+		_ = RenderPartialFromHeader(header)
+	}
+}
+
 type dummyWriter struct {
 	io.Writer
 }
@@ -250,6 +1483,21 @@ func (dummyWriter) Header() http.Header {
 }
 func (dummyWriter) WriteHeader(int) {}
 
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+type recordLogger struct {
+	entries []string
+}
+
+func (l *recordLogger) Debug(msg string, _ ...any) { l.entries = append(l.entries, "debug: "+msg) }
+func (l *recordLogger) Info(msg string, _ ...any)  { l.entries = append(l.entries, "info: "+msg) }
+func (l *recordLogger) Warn(msg string, _ ...any)  { l.entries = append(l.entries, "warn: "+msg) }
+func (l *recordLogger) Error(msg string, _ ...any) { l.entries = append(l.entries, "error: "+msg) }
+
 func equalBool(t *testing.T, expected, actual bool) {
 	if expected != actual {
 		t.Errorf("expected %t, got %t", expected, actual)
@@ -0,0 +1,8 @@
+module github.com/developersismedika/go-htmx/htmxchi
+
+go 1.20
+
+require (
+	github.com/developersismedika/go-htmx v0.0.0-20261014035140-30fc010b727b
+	github.com/go-chi/chi/v5 v5.0.12
+)
@@ -0,0 +1,19 @@
+// Package htmxchi wires a htmx instance into a chi router.
+// It lives in its own module so the htmx package does not depend on chi.
+package htmxchi
+
+import (
+	"net/http"
+
+	"github.com/developersismedika/go-htmx"
+)
+
+// Middleware returns the Middleware of h as a chi middleware, for r.Use(htmxchi.Middleware(h)).
+func Middleware(h *htmx.HTMX) func(http.Handler) http.Handler {
+	return h.Middleware
+}
+
+// Handler returns the htmx Handler the Middleware stored for the request.
+func Handler(r *http.Request) (*htmx.Handler, bool) {
+	return htmx.FromContext(r.Context())
+}
@@ -0,0 +1,36 @@
+package htmxchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developersismedika/go-htmx"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware(htmx.New()))
+	r.Get("/pokemon/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h, ok := Handler(r)
+		if !ok {
+			t.Fatal("expected a htmx handler")
+		}
+
+		h.ReTarget("#details")
+		h.JustWriteString("pokemon " + chi.URLParam(r, "id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/pokemon/25", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if got := rec.Body.String(); got != "pokemon 25" {
+		t.Errorf("expected pokemon 25, got %s", got)
+	}
+	if got := rec.Header().Get("HX-Retarget"); got != "#details" {
+		t.Errorf("expected #details, got %s", got)
+	}
+}
@@ -0,0 +1,42 @@
+// Package htmxecho wires a htmx instance into an echo server.
+// It lives in its own module so the htmx package does not depend on echo.
+package htmxecho
+
+import (
+	"net/http"
+
+	"github.com/developersismedika/go-htmx"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns the Middleware of h as an echo middleware, for e.Use(htmxecho.Middleware(h)).
+// The response of the echo context writes through the htmx Handler while next runs, so the staged htmx headers
+// are committed with the status. An error returned by next is passed to the echo error handler, with c.Error as
+// echo's Logger middleware does, before the Handler is released, so the error response is not preempted by the
+// commit of the staged htmx headers. Use Handler.Error in next for an htmx error response instead.
+func Middleware(h *htmx.HTMX) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			resp := c.Response()
+
+			var err error
+			h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// the htmx Handler writes to resp, which keeps track of the committed status and size on its own
+				c.SetRequest(r)
+				c.SetResponse(echo.NewResponse(w, c.Echo()))
+
+				if err = next(c); err != nil {
+					c.Error(err)
+				}
+			})).ServeHTTP(resp, c.Request())
+
+			c.SetResponse(resp)
+			return err
+		}
+	}
+}
+
+// Handler returns the htmx Handler the Middleware stored for the request of c.
+func Handler(c echo.Context) (*htmx.Handler, bool) {
+	return htmx.FromContext(c.Request().Context())
+}
@@ -0,0 +1,78 @@
+package htmxecho
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developersismedika/go-htmx"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware(htmx.New()))
+	e.GET("/pokemon/:id", func(c echo.Context) error {
+		h, ok := Handler(c)
+		if !ok {
+			return errors.New("expected a htmx handler")
+		}
+
+		h.ReTarget("#details")
+		return c.String(http.StatusOK, "pokemon "+c.Param("id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/pokemon/25", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != "pokemon 25" {
+		t.Errorf("expected pokemon 25, got %s", got)
+	}
+	if got := rec.Header().Get("HX-Retarget"); got != "#details" {
+		t.Errorf("expected #details, got %s", got)
+	}
+}
+
+func TestMiddlewareError(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware(htmx.New()))
+	e.GET("/", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot, "short and stout")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("expected 418, got %d", rec.Code)
+	}
+}
+
+func TestMiddlewareErrorAfterStaging(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware(htmx.New()))
+	e.GET("/", func(c echo.Context) error {
+		h, _ := Handler(c)
+		h.ReTarget("#box")
+
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if rec.Body.Len() == 0 {
+		t.Error("expected the echo error response, got an empty body")
+	}
+}
@@ -0,0 +1,63 @@
+// Package htmxgin wires a htmx instance into a gin engine.
+// It lives in its own module so the htmx package does not depend on gin.
+package htmxgin
+
+import (
+	"net/http"
+
+	"github.com/developersismedika/go-htmx"
+	"github.com/gin-gonic/gin"
+)
+
+// responseWriter sends what gin writes through the htmx Handler, so the staged htmx headers are committed with the
+// status.
+type responseWriter struct {
+	gin.ResponseWriter
+	handler *htmx.Handler
+}
+
+// Middleware returns the Middleware of h as a gin middleware, for r.Use(htmxgin.Middleware(h)).
+// The writer of the gin context writes through the htmx Handler while the rest of the chain runs, so c.Status
+// and the render methods of gin commit the staged htmx headers: call the htmx setters before them.
+func Middleware(h *htmx.HTMX) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := c.Writer
+
+		h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler, _ := htmx.FromContext(r.Context())
+
+			c.Request = r
+			c.Writer = &responseWriter{ResponseWriter: writer, handler: handler}
+			c.Next()
+		})).ServeHTTP(writer, c.Request)
+
+		c.Writer = writer
+	}
+}
+
+// Handler returns the htmx Handler the Middleware stored for the request of c.
+func Handler(c *gin.Context) (*htmx.Handler, bool) {
+	return htmx.FromContext(c.Request.Context())
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.handler.WriteHeader(code)
+}
+
+// WriteHeaderNow commits the status gin recorded, gin calls it for responses without body.
+func (w *responseWriter) WriteHeaderNow() {
+	w.handler.WriteHeader(w.ResponseWriter.Status())
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	return w.handler.Write(p)
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	return w.handler.WriteString(s)
+}
+
+func (w *responseWriter) Flush() {
+	w.handler.Flush()
+}
@@ -0,0 +1,54 @@
+package htmxgin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developersismedika/go-htmx"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Middleware(htmx.New()))
+	r.GET("/pokemon/:id", func(c *gin.Context) {
+		h, ok := Handler(c)
+		if !ok {
+			t.Fatal("expected a htmx handler")
+		}
+
+		h.ReTarget("#details")
+		c.String(http.StatusOK, "pokemon %s", c.Param("id"))
+	})
+	r.DELETE("/pokemon/:id", func(c *gin.Context) {
+		h, _ := Handler(c)
+
+		h.TriggerSuccess("released")
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/pokemon/25", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if got := rec.Body.String(); got != "pokemon 25" {
+		t.Errorf("expected pokemon 25, got %s", got)
+	}
+	if got := rec.Header().Get("HX-Retarget"); got != "#details" {
+		t.Errorf("expected #details, got %s", got)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/pokemon/25", nil))
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("HX-Trigger"); got == "" {
+		t.Error("expected the triggered event")
+	}
+}
@@ -0,0 +1,67 @@
+package htmxtest
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// FakeClock is a htmx.Clock whose time only moves with Advance, see htmx.WithClock and htmx.WithHubClock.
+	FakeClock struct {
+		mu      sync.Mutex
+		now     time.Time
+		waiters []fakeWaiter
+	}
+
+	fakeWaiter struct {
+		at time.Time
+		ch chan time.Time
+	}
+)
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel receiving the time once the clock was advanced by d, at once when d is not positive.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d and fires the channels of After that are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiting = append(waiting, w)
+			continue
+		}
+
+		w.ch <- c.now
+	}
+	c.waiters = waiting
+}
@@ -0,0 +1,82 @@
+package htmxtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/developersismedika/go-htmx"
+)
+
+func TestFakeClockPollLimit(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	limit := htmx.New(htmx.WithClock(clock)).PollLimit(time.Second)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		limit.ServeHTTP(rec, NewRequest(http.MethodGet, "/poll").Hx().Build())
+		return rec.Code
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, code)
+	}
+	if code := serve(); code != htmx.StatusStopPolling {
+		t.Fatalf("expected %d, got %d", htmx.StatusStopPolling, code)
+	}
+
+	clock.Advance(time.Second)
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, code)
+	}
+}
+
+func TestFakeClockAfter(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	after := clock.After(time.Minute)
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-after:
+		t.Fatal("fired before the deadline")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case fired := <-after:
+		if !fired.Equal(clock.Now()) {
+			t.Errorf("expected %s, got %s", clock.Now(), fired)
+		}
+	default:
+		t.Fatal("did not fire at the deadline")
+	}
+}
+
+func TestFakeClockDedupBoosted(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := htmx.NewMemoryIdempotencyStore(htmx.WithIdempotencyStoreClock(clock))
+
+	calls := 0
+	dedup := htmx.New().DedupBoosted(store)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls++
+	}))
+
+	serve := func() {
+		dedup.ServeHTTP(httptest.NewRecorder(), NewRequest(http.MethodGet, "/pokemon").Boosted().
+			Header(htmx.DedupNonceHeader, "n1").Build())
+	}
+
+	serve()
+	serve()
+	if calls != 1 {
+		t.Fatalf("expected 1 call within the window, got %d", calls)
+	}
+
+	clock.Advance(time.Second)
+	serve()
+	if calls != 2 {
+		t.Fatalf("expected 2 calls once the window passed, got %d", calls)
+	}
+}
@@ -0,0 +1,77 @@
+// Package htmxtest provides helpers to test handlers built with the htmx package.
+package htmxtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/developersismedika/go-htmx"
+)
+
+// RequestBuilder builds requests carrying htmx request headers.
+type RequestBuilder struct {
+	r *http.Request
+}
+
+// NewRequest returns a builder for a request to target, see httptest.NewRequest.
+func NewRequest(method, target string) *RequestBuilder {
+	return &RequestBuilder{
+		r: httptest.NewRequest(method, target, nil),
+	}
+}
+
+func (b *RequestBuilder) set(k htmx.HxRequestHeaderKey, val string) *RequestBuilder {
+	b.r.Header.Set(k.String(), val)
+	return b
+}
+
+// Hx marks the request as a htmx request.
+func (b *RequestBuilder) Hx() *RequestBuilder {
+	return b.set(htmx.HxRequestHeaderRequest, "true")
+}
+
+// Boosted marks the request as a boosted htmx request, like htmx does it also sets HX-Request.
+func (b *RequestBuilder) Boosted() *RequestBuilder {
+	return b.Hx().set(htmx.HxRequestHeaderBoosted, "true")
+}
+
+// HistoryRestore marks the request as a htmx history restore request.
+func (b *RequestBuilder) HistoryRestore() *RequestBuilder {
+	return b.Hx().set(htmx.HxRequestHeaderHistoryRestoreRequest, "true")
+}
+
+// Target sets the id of the target element.
+func (b *RequestBuilder) Target(id string) *RequestBuilder {
+	return b.set(htmx.HxRequestHeaderTarget, id)
+}
+
+// Trigger sets the id of the triggered element.
+func (b *RequestBuilder) Trigger(id string) *RequestBuilder {
+	return b.set(htmx.HxRequestHeaderTrigger, id)
+}
+
+// TriggerName sets the name of the triggered element.
+func (b *RequestBuilder) TriggerName(name string) *RequestBuilder {
+	return b.set(htmx.HxRequestHeaderTriggerName, name)
+}
+
+// Prompt sets the user response to an hx-prompt.
+func (b *RequestBuilder) Prompt(text string) *RequestBuilder {
+	return b.set(htmx.HxRequestHeaderPrompt, text)
+}
+
+// CurrentURL sets the current URL of the browser.
+func (b *RequestBuilder) CurrentURL(url string) *RequestBuilder {
+	return b.set(htmx.HxRequestHeaderCurrentURL, url)
+}
+
+// Header sets any other request header.
+func (b *RequestBuilder) Header(key, val string) *RequestBuilder {
+	b.r.Header.Set(key, val)
+	return b
+}
+
+// Build returns the request.
+func (b *RequestBuilder) Build() *http.Request {
+	return b.r
+}
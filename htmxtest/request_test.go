@@ -0,0 +1,55 @@
+package htmxtest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/developersismedika/go-htmx"
+)
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		isHxRequest    bool
+		renderPartial  bool
+		historyRestore bool
+	}{
+		{name: "full page", request: NewRequest(http.MethodGet, "/").Build()},
+		{name: "htmx", request: NewRequest(http.MethodGet, "/").Hx().Build(), isHxRequest: true, renderPartial: true},
+		{name: "boosted", request: NewRequest(http.MethodGet, "/").Boosted().Build(), isHxRequest: true, renderPartial: true},
+		{name: "history restore", request: NewRequest(http.MethodGet, "/").HistoryRestore().Build(), isHxRequest: true, historyRestore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if htmx.IsHxRequest(tt.request) != tt.isHxRequest {
+				t.Errorf("expected IsHxRequest to be %t", tt.isHxRequest)
+			}
+			if htmx.RenderPartial(tt.request) != tt.renderPartial {
+				t.Errorf("expected RenderPartial to be %t", tt.renderPartial)
+			}
+			if htmx.IsHxHistoryRestoreRequest(tt.request) != tt.historyRestore {
+				t.Errorf("expected IsHxHistoryRestoreRequest to be %t", tt.historyRestore)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	r := NewRequest(http.MethodPost, "/items").
+		Hx().
+		Target("list").
+		Trigger("save").
+		TriggerName("action").
+		Prompt("yes").
+		CurrentURL("http://example.com/items").
+		Build()
+
+	header := htmx.HxRequestHeaderFromRequest(r)
+
+	if header.HxTarget != "list" || header.HxTrigger != "save" || header.HxTriggerName != "action" ||
+		header.HxPrompt != "yes" || header.HxCurrentURL != "http://example.com/items" {
+		t.Errorf("unexpected request header %+v", header)
+	}
+}
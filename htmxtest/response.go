@@ -0,0 +1,106 @@
+package htmxtest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/developersismedika/go-htmx"
+)
+
+type (
+	// Response holds the htmx response headers of a recorded response.
+	Response struct {
+		Status             int
+		Location           string
+		PushURL            string
+		Redirect           string
+		Refresh            bool
+		ReplaceURL         string
+		Reswap             string
+		Retarget           string
+		Reselect           string
+		Trigger            map[string]Event
+		TriggerAfterSettle map[string]Event
+		TriggerAfterSwap   map[string]Event
+	}
+
+	// Event is a single event of a HX-Trigger header.
+	Event struct {
+		// Detail is the raw JSON detail of the event, nil when the header used the comma separated form
+		Detail json.RawMessage
+		// Level and Message are read from an object detail, as sent by the notification helpers
+		Level   string
+		Message string
+	}
+)
+
+// ParseResponse decodes the htmx response headers of the recorded response.
+// Trigger headers that fail to parse are left empty, use ParseTrigger to get the error.
+func ParseResponse(rec *httptest.ResponseRecorder) Response {
+	header := rec.Result().Header
+
+	get := func(k htmx.HxResponseKey) string {
+		return header.Get(k.String())
+	}
+
+	resp := Response{
+		Status:     rec.Code,
+		Location:   get(htmx.HXLocation),
+		PushURL:    get(htmx.HXPushUrl),
+		Redirect:   get(htmx.HXRedirect),
+		Refresh:    htmx.HxStrToBool(get(htmx.HXRefresh)),
+		ReplaceURL: get(htmx.HXReplaceUrl),
+		Reswap:     get(htmx.HXReswap),
+		Retarget:   get(htmx.HXRetarget),
+		Reselect:   get(htmx.HXReselect),
+	}
+
+	resp.Trigger, _ = ParseTrigger(get(htmx.HXTrigger))
+	resp.TriggerAfterSettle, _ = ParseTrigger(get(htmx.HXTriggerAfterSettle))
+	resp.TriggerAfterSwap, _ = ParseTrigger(get(htmx.HXTriggerAfterSwap))
+
+	return resp
+}
+
+// ParseTrigger decodes a HX-Trigger header value, both the comma separated and the JSON object form.
+func ParseTrigger(val string) (map[string]Event, error) {
+	events := make(map[string]Event)
+
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return events, nil
+	}
+
+	if !strings.HasPrefix(val, "{") {
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				events[name] = Event{}
+			}
+		}
+
+		return events, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(val), &raw); err != nil {
+		return events, err
+	}
+
+	for name, detail := range raw {
+		event := Event{Detail: detail}
+
+		var notification struct {
+			Level   string `json:"level"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(detail, &notification) == nil {
+			event.Level = notification.Level
+			event.Message = notification.Message
+		}
+
+		events[name] = event
+	}
+
+	return events, nil
+}
@@ -0,0 +1,61 @@
+package htmxtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developersismedika/go-htmx"
+)
+
+func TestParseResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	h := htmx.New().NewHandler(rec, NewRequest(http.MethodPost, "/items").Hx().Build())
+
+	h.ReTarget("#list").ReSwap("outerHTML").PushURL("/items")
+	h.Redirect("/done")
+	h.TriggerSuccess("saved")
+	h.TriggerAfterSwap("focus, highlight")
+	h.WriteHeader(http.StatusCreated)
+
+	resp := ParseResponse(rec)
+
+	if resp.Status != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, resp.Status)
+	}
+	if resp.Retarget != "#list" || resp.Reswap != "outerHTML" || resp.PushURL != "/items" || resp.Redirect != "/done" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if resp.Trigger["showMessage"].Message != "saved" || resp.Trigger["showMessage"].Level != "success" {
+		t.Errorf("unexpected trigger %+v", resp.Trigger)
+	}
+	if _, ok := resp.TriggerAfterSwap["highlight"]; !ok || len(resp.TriggerAfterSwap) != 2 {
+		t.Errorf("unexpected after swap trigger %+v", resp.TriggerAfterSwap)
+	}
+}
+
+func TestParseResponseRefresh(t *testing.T) {
+	rec := httptest.NewRecorder()
+	h := htmx.New().NewHandler(rec, NewRequest(http.MethodPost, "/logout").Hx().Build())
+
+	h.Refresh(true)
+	h.WriteHeader(http.StatusOK)
+
+	if resp := ParseResponse(rec); !resp.Refresh || resp.Redirect != "" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	events, err := ParseTrigger(`{"count":3,"refresh":""}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(events["count"].Detail) != "3" {
+		t.Errorf("unexpected detail %s", events["count"].Detail)
+	}
+
+	if _, err = ParseTrigger(`{"broken"`); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
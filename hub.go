@@ -0,0 +1,313 @@
+package htmx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultHubBacklog is the number of messages a hub client may lag behind before it is dropped.
+var DefaultHubBacklog = 16
+
+type (
+	// Hub fans out server sent events to all registered clients.
+	// Every client has its own buffered queue, a client whose queue is full is dropped instead of blocking the broadcaster.
+	Hub struct {
+		mu      sync.RWMutex
+		clients map[string]*hubClient
+		backlog int
+		closed  bool
+		clock   Clock
+
+		// pending holds the coalesced events waiting for their window to end, see BroadcastCoalesced
+		pendingMu sync.Mutex
+		pending   map[string]*pendingEvent
+	}
+
+	pendingEvent struct {
+		data string
+		stop chan struct{}
+	}
+
+	// HubOption configures a Hub, see NewHub.
+	HubOption func(*Hub)
+
+	hubClient struct {
+		messages chan hubMessage
+		quit     chan struct{}
+		done     chan struct{}
+		once     sync.Once
+
+		// drain asks the pump to send the queued messages and stop, see Shutdown
+		drain     chan struct{}
+		drainOnce sync.Once
+	}
+
+	hubMessage struct {
+		event string
+		data  string
+	}
+)
+
+// NewHub returns a new hub.
+func NewHub(opts ...HubOption) *Hub {
+	hub := &Hub{
+		clients: make(map[string]*hubClient),
+		backlog: DefaultHubBacklog,
+		clock:   realClock{},
+		pending: make(map[string]*pendingEvent),
+	}
+
+	for _, opt := range opts {
+		opt(hub)
+	}
+
+	return hub
+}
+
+// WithHubBacklog sets the number of queued messages after which a slow client is dropped.
+func WithHubBacklog(n int) HubOption {
+	return func(hub *Hub) {
+		hub.backlog = n
+	}
+}
+
+// WithHubClock sets the clock timing the windows of BroadcastCoalesced, e.g. a fake clock in tests, see
+// htmxtest.FakeClock. nil restores the real clock.
+func WithHubClock(c Clock) HubOption {
+	return func(hub *Hub) {
+		if c == nil {
+			c = realClock{}
+		}
+
+		hub.clock = c
+	}
+}
+
+// Register starts a server sent event stream on the handler and adds it to the hub.
+// The returned channel is closed once the client is gone, because it disconnected, was unregistered,
+// was dropped for being too slow or because the hub was closed. The http handler should block on it.
+// Once the hub is closed or shutting down the client gets 503 Service Unavailable instead of a stream, which stops
+// EventSource from reconnecting, and the returned channel is already closed.
+func (hub *Hub) Register(h *Handler) (clientID string, done <-chan struct{}) {
+	c := &hubClient{
+		messages: make(chan hubMessage, hub.backlog),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		drain:    make(chan struct{}),
+	}
+
+	hub.mu.Lock()
+	closed := hub.closed
+	hub.mu.Unlock()
+
+	if closed {
+		h.log.Debug("htmx: rejecting hub client, the hub is closed")
+		http.Error(h, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		close(c.done)
+		return "", c.done
+	}
+
+	sse, err := h.SSE()
+	if err != nil {
+		h.log.Error("htmx: unable to register hub client", "error", err)
+		close(c.done)
+		return "", c.done
+	}
+
+	clientID = newClientID()
+
+	hub.mu.Lock()
+	if hub.closed {
+		hub.mu.Unlock()
+		close(c.done)
+		return "", c.done
+	}
+	hub.clients[clientID] = c
+	hub.mu.Unlock()
+
+	go hub.pump(clientID, c, sse)
+
+	return clientID, c.done
+}
+
+// Unregister removes the client from the hub, its done channel is closed shortly after.
+func (hub *Hub) Unregister(clientID string) {
+	hub.mu.Lock()
+	c, ok := hub.clients[clientID]
+	delete(hub.clients, clientID)
+	hub.mu.Unlock()
+
+	if ok {
+		c.stop()
+	}
+}
+
+// Broadcast queues the event for every registered client, it is a no-op once the hub is closed or shutting down.
+func (hub *Hub) Broadcast(event, data string) {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+
+	if hub.closed {
+		return
+	}
+
+	msg := hubMessage{event: event, data: data}
+	for _, c := range hub.clients {
+		select {
+		case c.messages <- msg:
+		default:
+			// the client can not keep up, drop it rather than blocking everyone else
+			c.stop()
+		}
+	}
+}
+
+// BroadcastCoalesced broadcasts the event once the window has passed, events of the same name broadcast in the
+// meantime only replace the data, so clients of high churn data get the latest value instead of a flood.
+// The trade-off is latency: the first event of a window is delayed by up to the window.
+func (hub *Hub) BroadcastCoalesced(event, data string, window time.Duration) {
+	hub.pendingMu.Lock()
+	defer hub.pendingMu.Unlock()
+
+	if p, ok := hub.pending[event]; ok {
+		p.data = data
+		return
+	}
+
+	p := &pendingEvent{data: data, stop: make(chan struct{})}
+	elapsed := hub.clock.After(window)
+	go func() {
+		select {
+		case <-elapsed:
+		case <-p.stop:
+			return
+		}
+
+		hub.pendingMu.Lock()
+		data := p.data
+		delete(hub.pending, event)
+		hub.pendingMu.Unlock()
+
+		hub.Broadcast(event, data)
+	}()
+	hub.pending[event] = p
+}
+
+// Close stops all clients, registering afterward is a no-op. Coalesced events still waiting are dropped.
+func (hub *Hub) Close() {
+	hub.pendingMu.Lock()
+	for event, p := range hub.pending {
+		close(p.stop)
+		delete(hub.pending, event)
+	}
+	hub.pendingMu.Unlock()
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.closed = true
+	for id, c := range hub.clients {
+		c.stop()
+		delete(hub.clients, id)
+	}
+}
+
+// Shutdown stops accepting broadcasts and registrations, like Close, but lets every client send the messages
+// still queued before its stream ends, so the last update reaches connected clients during a graceful shutdown.
+// Coalesced events still waiting for their window are broadcast right away.
+// When ctx is done before all clients are drained, the remaining ones are stopped and ctx.Err() is returned.
+func (hub *Hub) Shutdown(ctx context.Context) error {
+	hub.pendingMu.Lock()
+	for event, p := range hub.pending {
+		close(p.stop)
+		delete(hub.pending, event)
+		hub.Broadcast(event, p.data)
+	}
+	hub.pendingMu.Unlock()
+
+	hub.mu.Lock()
+	hub.closed = true
+	clients := make(map[string]*hubClient, len(hub.clients))
+	for id, c := range hub.clients {
+		clients[id] = c
+		c.drainOnce.Do(func() {
+			close(c.drain)
+		})
+	}
+	hub.mu.Unlock()
+
+	for id, c := range clients {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			for id, c := range clients {
+				c.stop()
+				hub.remove(id, c)
+			}
+			return ctx.Err()
+		}
+		delete(clients, id)
+	}
+
+	return nil
+}
+
+// pump writes the queued messages of a client to its stream until the client is stopped or gone.
+func (hub *Hub) pump(clientID string, c *hubClient, sse *SSEWriter) {
+	defer close(c.done)
+	defer hub.remove(clientID, c)
+
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-sse.Done():
+			return
+		case msg := <-c.messages:
+			if err := sse.SendEvent(msg.event, msg.data); err != nil {
+				return
+			}
+		case <-c.drain:
+			// no message is queued anymore once the hub is shutting down, send what is left and stop
+			for {
+				select {
+				case <-c.quit:
+					return
+				case msg := <-c.messages:
+					if err := sse.SendEvent(msg.event, msg.data); err != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// remove deletes the client from the hub, unless the id has been taken over in the meantime.
+func (hub *Hub) remove(clientID string, c *hubClient) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.clients[clientID] == c {
+		delete(hub.clients, clientID)
+	}
+}
+
+func (c *hubClient) stop() {
+	c.once.Do(func() {
+		close(c.quit)
+	})
+}
+
+func newClientID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
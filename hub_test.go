@@ -0,0 +1,208 @@
+package htmx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter is a flushing response writer whose writes block until release is closed
+type blockingWriter struct {
+	*httptest.ResponseRecorder
+	release chan struct{}
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return b.ResponseRecorder.Write(p)
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the client to be done")
+	}
+}
+
+func TestHubBroadcast(t *testing.T) {
+	hub := NewHub()
+
+	rec := httptest.NewRecorder()
+	id, done := hub.Register(New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/events", nil)))
+	if id == "" {
+		t.Fatal("expected a client id")
+	}
+
+	hub.Broadcast("message", "hello")
+
+	// wait for the message to be consumed before unregistering
+	deadline := time.Now().Add(time.Second)
+	for {
+		hub.mu.RLock()
+		queued := len(hub.clients[id].messages)
+		hub.mu.RUnlock()
+		if queued == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	hub.Unregister(id)
+	waitDone(t, done)
+
+	equal(t, "event: message\ndata: hello\n\n", rec.Body.String())
+}
+
+func TestHubDropsSlowClients(t *testing.T) {
+	hub := NewHub(WithHubBacklog(1))
+
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), release: make(chan struct{})}
+	_, done := hub.Register(New().NewHandler(w, httptest.NewRequest(http.MethodGet, "/events", nil)))
+
+	for i := 0; i < 5; i++ {
+		hub.Broadcast("message", strconv.Itoa(i))
+	}
+	close(w.release)
+
+	waitDone(t, done)
+
+	hub.mu.RLock()
+	equalInt(t, 0, len(hub.clients))
+	hub.mu.RUnlock()
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub()
+
+	_, done := hub.Register(New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil)))
+	hub.Close()
+	waitDone(t, done)
+
+	rec := httptest.NewRecorder()
+	id, done := hub.Register(New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/events", nil)))
+	equal(t, "", id)
+	waitDone(t, done)
+	equalInt(t, http.StatusServiceUnavailable, rec.Code)
+	equalBool(t, false, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/event-stream"))
+}
+
+func TestHubShutdown(t *testing.T) {
+	hub := NewHub()
+
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), release: make(chan struct{})}
+	_, done := hub.Register(New().NewHandler(w, httptest.NewRequest(http.MethodGet, "/events", nil)))
+
+	for i := 0; i < 3; i++ {
+		hub.Broadcast("message", strconv.Itoa(i))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- hub.Shutdown(ctx)
+	}()
+
+	close(w.release)
+
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	waitDone(t, done)
+
+	equal(t, "event: message\ndata: 0\n\nevent: message\ndata: 1\n\nevent: message\ndata: 2\n\n", w.Body.String())
+
+	id, done := hub.Register(New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil)))
+	equal(t, "", id)
+	waitDone(t, done)
+}
+
+func TestHubShutdownDeadline(t *testing.T) {
+	hub := NewHub()
+
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), release: make(chan struct{})}
+	_, done := hub.Register(New().NewHandler(w, httptest.NewRequest(http.MethodGet, "/events", nil)))
+	hub.Broadcast("message", "stuck")
+	hub.Broadcast("message", "queued")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := hub.Shutdown(ctx)
+	equalBool(t, true, errors.Is(err, context.DeadlineExceeded))
+
+	close(w.release)
+	waitDone(t, done)
+}
+
+func TestHubConcurrency(t *testing.T) {
+	hub := NewHub()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			id, done := hub.Register(New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil)))
+			hub.Unregister(id)
+			<-done
+		}()
+		go func() {
+			defer wg.Done()
+			hub.Broadcast("message", "hi")
+		}()
+	}
+	wg.Wait()
+
+	hub.Close()
+}
+
+func TestHubBroadcastCoalesced(t *testing.T) {
+	hub := NewHub()
+
+	rec := httptest.NewRecorder()
+	id, done := hub.Register(New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/events", nil)))
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hub.BroadcastCoalesced("price", strconv.Itoa(i), 50*time.Millisecond)
+		}(i)
+	}
+	wg.Wait()
+	hub.BroadcastCoalesced("price", "latest", 50*time.Millisecond)
+
+	// wait for the window to end and the message to be consumed
+	deadline := time.Now().Add(time.Second)
+	for {
+		hub.pendingMu.Lock()
+		pending := len(hub.pending)
+		hub.pendingMu.Unlock()
+
+		hub.mu.RLock()
+		queued := len(hub.clients[id].messages)
+		hub.mu.RUnlock()
+
+		if (pending == 0 && queued == 0) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	hub.Unregister(id)
+	waitDone(t, done)
+
+	equal(t, "event: price\ndata: latest\n\n", rec.Body.String())
+}
@@ -0,0 +1,236 @@
+package htmx
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// IdempotencyStore keeps the responses of Idempotent for replay, an implementation backed by Redis or a database
+	// lets several instances share them. NewMemoryIdempotencyStore returns an in-memory store.
+	IdempotencyStore interface {
+		// Get returns the response stored for key, if it did not expire.
+		Get(key string) (IdempotentResponse, bool)
+		// Set stores the response for key during ttl.
+		Set(key string, resp IdempotentResponse, ttl time.Duration)
+	}
+
+	// IdempotentResponse is a response recorded by Idempotent.
+	IdempotentResponse struct {
+		Status int
+		Header http.Header
+		Body   []byte
+	}
+
+	// MemoryIdempotencyStore is an in-memory IdempotencyStore, expired responses are dropped as new ones are stored.
+	MemoryIdempotencyStore struct {
+		mu        sync.Mutex
+		responses map[string]memoryIdempotentResponse
+		clock     Clock
+	}
+
+	// MemoryIdempotencyStoreOption configures NewMemoryIdempotencyStore.
+	MemoryIdempotencyStoreOption func(*MemoryIdempotencyStore)
+
+	memoryIdempotentResponse struct {
+		resp    IdempotentResponse
+		expires time.Time
+	}
+
+	// replayer runs a handler once per key and replays its stored response to the requests repeating the key.
+	replayer struct {
+		htmx  *HTMX
+		store IdempotencyStore
+
+		mu       sync.Mutex
+		inflight map[string]chan struct{}
+
+		// waiting is called when a request starts waiting for the one running with the same key, nil outside tests
+		waiting func(key string)
+	}
+
+	// idempotencyRecorder passes the response to the client while recording it for the store.
+	idempotencyRecorder struct {
+		http.ResponseWriter
+		resp        IdempotentResponse
+		body        bytes.Buffer
+		wroteHeader bool
+	}
+)
+
+// NewMemoryIdempotencyStore returns an empty in-memory IdempotencyStore.
+func NewMemoryIdempotencyStore(opts ...MemoryIdempotencyStoreOption) *MemoryIdempotencyStore {
+	s := &MemoryIdempotencyStore{
+		responses: make(map[string]memoryIdempotentResponse),
+		clock:     realClock{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithIdempotencyStoreClock sets the clock expiring the stored responses, e.g. a fake clock in tests, see
+// htmxtest.FakeClock. nil restores the real clock.
+func WithIdempotencyStoreClock(c Clock) MemoryIdempotencyStoreOption {
+	return func(s *MemoryIdempotencyStore) {
+		if c == nil {
+			c = realClock{}
+		}
+
+		s.clock = c
+	}
+}
+
+// Get returns the response stored for key, if it did not expire.
+func (s *MemoryIdempotencyStore) Get(key string) (IdempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.responses[key]
+	if !ok || !s.clock.Now().Before(stored.expires) {
+		return IdempotentResponse{}, false
+	}
+
+	return stored.resp, true
+}
+
+// Set stores the response for key during ttl.
+func (s *MemoryIdempotencyStore) Set(key string, resp IdempotentResponse, ttl time.Duration) {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, stored := range s.responses {
+		if !now.Before(stored.expires) {
+			delete(s.responses, k)
+		}
+	}
+
+	s.responses[key] = memoryIdempotentResponse{resp: resp, expires: now.Add(ttl)}
+}
+
+// Idempotent replays the stored response of unsafe requests repeating an idempotency key, sent in the
+// DefaultIdempotencyHeader request header, instead of running next again, so double clicks and retries of a form
+// do not submit it twice. The key is scoped to the method and path. A request arriving while the first one with
+// the same key is still running waits for its response.
+// Responses are stored for DefaultIdempotencyTTL, see WithIdempotencyHeader and WithIdempotencyTTL, except
+// server errors, which the client may retry. Requests without a key and safe methods are passed to next.
+// Idempotent records what is written to the http.ResponseWriter, place it before the Middleware.
+func (h *HTMX) Idempotent(store IdempotencyStore) func(http.Handler) http.Handler {
+	replay := newReplayer(h, store)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(h.idempotencyHeader)
+			if key == "" || isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			replay.serve(w, r, key+" "+r.Method+" "+r.URL.Path, h.idempotencyTTL, next)
+		})
+	}
+}
+
+// newReplayer returns a replayer storing responses in store.
+func newReplayer(h *HTMX, store IdempotencyStore) *replayer {
+	return &replayer{
+		htmx:     h,
+		store:    store,
+		inflight: make(map[string]chan struct{}),
+	}
+}
+
+// serve replays the response stored for key, waiting for a request with the same key still running, or runs
+// next and stores its response during ttl, server errors excepted.
+func (p *replayer) serve(w http.ResponseWriter, r *http.Request, key string, ttl time.Duration, next http.Handler) {
+	for {
+		if resp, ok := p.store.Get(key); ok {
+			p.htmx.log.Debug("htmx: replaying stored response", "method", r.Method, "path", r.URL.Path)
+			resp.write(w)
+			return
+		}
+
+		p.mu.Lock()
+		done, running := p.inflight[key]
+		if !running {
+			p.inflight[key] = make(chan struct{})
+		}
+		p.mu.Unlock()
+
+		if !running {
+			break
+		}
+
+		if p.waiting != nil {
+			p.waiting(key)
+		}
+
+		select {
+		case <-done:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	rec := &idempotencyRecorder{ResponseWriter: w}
+	defer func() {
+		p.mu.Lock()
+		close(p.inflight[key])
+		delete(p.inflight, key)
+		p.mu.Unlock()
+	}()
+
+	next.ServeHTTP(rec, r)
+
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if rec.resp.Status >= http.StatusInternalServerError {
+		return
+	}
+
+	rec.resp.Body = rec.body.Bytes()
+	p.store.Set(key, rec.resp, ttl)
+}
+
+// write sends the stored response to the client.
+func (resp IdempotentResponse) write(w http.ResponseWriter) {
+	for k, v := range resp.Header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+func (w *idempotencyRecorder) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.resp.Status = code
+	w.resp.Header = w.Header().Clone()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *idempotencyRecorder) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying response writer, see http.ResponseController.
+func (w *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
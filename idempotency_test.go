@@ -0,0 +1,95 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIdempotent(t *testing.T) {
+	calls := 0
+	next := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		handler, _ := FromContext(r.Context())
+		handler.TriggerSuccess("created")
+		handler.WriteHeader(http.StatusCreated)
+		_, _ = handler.Write([]byte("item " + strconv.Itoa(calls)))
+	}))
+	idempotent := New().Idempotent(NewMemoryIdempotencyStore())(next)
+
+	serve := func(method, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/items", nil)
+		r.Header.Set("HX-Request", "true")
+		if key != "" {
+			r.Header.Set(DefaultIdempotencyHeader, key)
+		}
+
+		rec := httptest.NewRecorder()
+		idempotent.ServeHTTP(rec, r)
+		return rec
+	}
+
+	first := serve(http.MethodPost, "abc")
+	equalInt(t, http.StatusCreated, first.Code)
+	equal(t, "item 1", first.Body.String())
+
+	replay := serve(http.MethodPost, "abc")
+	equalInt(t, 1, calls)
+	equalInt(t, http.StatusCreated, replay.Code)
+	equal(t, "item 1", replay.Body.String())
+	equal(t, first.Header().Get(HXTrigger.String()), replay.Header().Get(HXTrigger.String()))
+
+	equal(t, "item 2", serve(http.MethodPost, "other").Body.String())
+	equal(t, "item 3", serve(http.MethodPost, "").Body.String())
+	equal(t, "item 4", serve(http.MethodGet, "abc").Body.String())
+	equalInt(t, 4, calls)
+}
+
+func TestIdempotentServerError(t *testing.T) {
+	calls := 0
+	idempotent := New().Idempotent(NewMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/items", nil)
+		r.Header.Set(DefaultIdempotencyHeader, "abc")
+		idempotent.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	equalInt(t, 2, calls)
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryIdempotencyStore(WithIdempotencyStoreClock(clock))
+
+	store.Set("abc", IdempotentResponse{Status: http.StatusOK}, time.Minute)
+
+	_, ok := store.Get("abc")
+	equalBool(t, true, ok)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, ok = store.Get("abc")
+	equalBool(t, false, ok)
+
+	store.Set("other", IdempotentResponse{}, time.Minute)
+	equalInt(t, 1, len(store.responses))
+}
+
+// stepClock is a Clock whose time is set by the test, see htmxtest.FakeClock for a clock with timers.
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	return c.now
+}
+
+func (c *stepClock) After(time.Duration) <-chan time.Time {
+	return nil
+}
@@ -0,0 +1,101 @@
+package htmx
+
+import "errors"
+
+// ErrLocationNoPath is returned by LocationBuilder.Send when no path is set, htmx needs one to load the response.
+var ErrLocationNoPath = errors.New("htmx: the location has no path")
+
+// LocationBuilder builds the HX-Location header of a handler field by field, see Handler.NewLocation.
+type LocationBuilder struct {
+	h  *Handler
+	li LocationInput
+}
+
+// NewLocation returns a builder for the HX-Location header, a client side redirection carrying the context of
+// the request htmx makes, e.g. h.NewLocation().Path("/x").Target("#main").Swap(SwapInnerHTML).Send().
+// Unset fields are omitted, a location with only a path is sent as the plain path.
+// https://htmx.org/headers/hx-location/
+func (h *Handler) NewLocation() *LocationBuilder {
+	return &LocationBuilder{h: h}
+}
+
+// Path sets the url to load the response from.
+func (b *LocationBuilder) Path(path string) *LocationBuilder {
+	b.li.Path = path
+	return b
+}
+
+// Source sets the source element of the request.
+func (b *LocationBuilder) Source(selector string) *LocationBuilder {
+	b.li.Source = selector
+	return b
+}
+
+// Event sets the event that triggered the request.
+func (b *LocationBuilder) Event(event string) *LocationBuilder {
+	b.li.Event = event
+	return b
+}
+
+// Target sets the element the response is swapped into.
+func (b *LocationBuilder) Target(selector string) *LocationBuilder {
+	b.li.Target = selector
+	return b
+}
+
+// Swap sets how the response is swapped in relative to the target.
+func (b *LocationBuilder) Swap(style SwapStyle) *LocationBuilder {
+	b.li.Swap = style.String()
+	return b
+}
+
+// Select sets the part of the response that is swapped in.
+func (b *LocationBuilder) Select(selector string) *LocationBuilder {
+	b.li.Select = selector
+	return b
+}
+
+// Values sets the values submitted with the request, replacing the ones set before.
+func (b *LocationBuilder) Values(values map[string]string) *LocationBuilder {
+	b.li.Values = stringMap(values)
+	return b
+}
+
+// Headers sets the headers submitted with the request, replacing the ones set before.
+func (b *LocationBuilder) Headers(headers map[string]string) *LocationBuilder {
+	b.li.Header = stringMap(headers)
+	return b
+}
+
+// Send sets the HX-Location header, see Handler.Location, and commits the response without a body, see
+// Handler.Commit. It returns ErrLocationNoPath without a path and ErrInvalidHeaderValue when the path contains a
+// line break, nothing is set or committed then.
+func (b *LocationBuilder) Send() error {
+	if b.li.Path == "" {
+		return ErrLocationNoPath
+	}
+	if _, err := sanitizeHeaderValue(b.li.Path); err != nil {
+		return err
+	}
+
+	li := b.li
+	if err := b.h.Location(&li); err != nil {
+		return err
+	}
+
+	return b.h.Commit()
+}
+
+// stringMap returns m as the map of a LocationInput, nil when m is empty so the field is omitted.
+func stringMap(m map[string]string) map[string]any {
+	if len(m) == 0 {
+		return nil
+	}
+
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+
+	return out
+}
@@ -0,0 +1,48 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewLocation(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if err := handler.NewLocation().Path("/pokemon").Send(); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "/pokemon", rec.Header().Get(HXLocation.String()))
+	equalInt(t, http.StatusOK, rec.Code)
+	equalInt(t, 0, rec.Body.Len())
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	err := handler.NewLocation().
+		Path("/pokemon/25").
+		Target("#main").
+		Swap(SwapInnerHTML).
+		Select("#details").
+		Values(map[string]string{"tab": "moves"}).
+		Headers(map[string]string{"X-Region": "kanto"}).
+		Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, `{"path":"/pokemon/25","target":"#main","swap":"innerHTML","values":{"tab":"moves"},"headers":{"X-Region":"kanto"},"select":"#details"}`, rec.Header().Get(HXLocation.String()))
+}
+
+func TestNewLocationInvalid(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	equalBool(t, true, errors.Is(handler.NewLocation().Target("#main").Send(), ErrLocationNoPath))
+	equalBool(t, true, errors.Is(handler.NewLocation().Path("/x\r\nSet-Cookie: a=b").Target("#main").Send(), ErrInvalidHeaderValue))
+
+	handler.Release()
+	equal(t, "", rec.Header().Get(HXLocation.String()))
+	equal(t, "", rec.Header().Get("Set-Cookie"))
+}
@@ -0,0 +1,21 @@
+package htmx
+
+type (
+	// Logger is the minimal logging interface used by the htmx instance and its handlers.
+	// The variadic arguments are alternating key/value pairs, which makes *slog.Logger and
+	// zap's SugaredLogger (through the zaplog adapter) drop-in implementations.
+	Logger interface {
+		Debug(msg string, keysAndValues ...any)
+		Info(msg string, keysAndValues ...any)
+		Warn(msg string, keysAndValues ...any)
+		Error(msg string, keysAndValues ...any)
+	}
+
+	// noopLogger discards everything, it is the default logger of a new htmx instance.
+	noopLogger struct{}
+)
+
+func (noopLogger) Debug(string, ...any) {}
+func (noopLogger) Info(string, ...any)  {}
+func (noopLogger) Warn(string, ...any)  {}
+func (noopLogger) Error(string, ...any) {}
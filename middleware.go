@@ -0,0 +1,250 @@
+package htmx
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type contextKey int
+
+const (
+	handlerContextKey contextKey = iota
+	annotationContextKey
+	preloadContextKey
+)
+
+// annotation is the request information stored by Annotate
+type annotation struct {
+	mode        Mode
+	boosted     bool
+	triggerID   string
+	triggerName string
+}
+
+// Middleware constructs a Handler for every request and stores it in the request context,
+// downstream handlers retrieve it with FromContext. The handler is released once next returns, which commits the
+// htmx response headers staged without writing a body, see Handler.Release.
+// next gets the Handler as its http.ResponseWriter, so writing to w directly also sends the staged headers.
+// Paths set with WithSkipPaths are passed to next untouched.
+// It also adds HX-Request to the Vary header so caches keep htmx and full page responses apart,
+// HX-Current-URL is added as soon as the handler reads it, see Handler.CurrentURL.
+func (h *HTMX) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.skipPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		addVary(w.Header(), h.requestPrefix+HxRequestHeaderRequest.String())
+
+		handler := h.NewHandler(w, r)
+		defer handler.Release()
+
+		handler.r = r.WithContext(context.WithValue(r.Context(), handlerContextKey, handler))
+
+		next.ServeHTTP(handler, handler.r)
+	})
+}
+
+// skipPath returns true when the path matches one of the prefixes set with WithSkipPaths.
+func (h *HTMX) skipPath(path string) bool {
+	for _, prefix := range h.skipPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FromContext returns the Handler stored by the Middleware, false when there is none, also on paths skipped
+// with WithSkipPaths.
+func FromContext(ctx context.Context) (*Handler, bool) {
+	handler, ok := ctx.Value(handlerContextKey).(*Handler)
+	return handler, ok
+}
+
+// Annotate stores the render mode, the boosted flag and the triggering element of the request in its context,
+// a lighter alternative to the Middleware for handlers that do not need a Handler.
+// Read them back with RenderModeFromContext, BoostedFromContext and TriggerFromContext.
+func (h *HTMX) Annotate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := h.HxHeader(r)
+
+		a := &annotation{
+			mode:        RenderModeFromHeader(header),
+			boosted:     header.HxBoosted,
+			triggerID:   strings.TrimSpace(header.HxTrigger),
+			triggerName: strings.TrimSpace(header.HxTriggerName),
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), annotationContextKey, a)))
+	})
+}
+
+// RenderModeFromContext returns the render mode stored by Annotate, ModeFull without it.
+func RenderModeFromContext(ctx context.Context) Mode {
+	if a, ok := ctx.Value(annotationContextKey).(*annotation); ok {
+		return a.mode
+	}
+
+	return ModeFull
+}
+
+// BoostedFromContext returns whether the request is boosted as stored by Annotate, false without it.
+func BoostedFromContext(ctx context.Context) bool {
+	a, ok := ctx.Value(annotationContextKey).(*annotation)
+	return ok && a.boosted
+}
+
+// TriggerFromContext returns the id and name of the triggering element stored by Annotate, see
+// Handler.TriggeringElement. Both are empty without it.
+func TriggerFromContext(ctx context.Context) (id, name string) {
+	if a, ok := ctx.Value(annotationContextKey).(*annotation); ok {
+		return a.triggerID, a.triggerName
+	}
+
+	return "", ""
+}
+
+// RequireHxRequest only lets htmx requests through to the next handler, everything else is passed to onReject.
+// A nil onReject responds with 400 Bad Request. Boosted requests are rejected unless allowBoosted is set.
+// Both outcomes add HX-Request to the Vary header so a rejection is never served to htmx clients from a cache.
+func RequireHxRequest(onReject http.Handler, allowBoosted bool) func(http.Handler) http.Handler {
+	if onReject == nil {
+		onReject = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addVary(w.Header(), HxRequestHeaderRequest.String())
+
+			if !IsHxRequest(r) || (!allowBoosted && IsHxBoosted(r)) {
+				onReject.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireTarget rejects htmx requests whose HX-Target is not one of the allowed element ids with
+// 400 Bad Request, responded with Handler.Error so the message is retargeted to the error target of the htmx
+// instance. Requests without target are let through, see Handler.TargetAllowed.
+func (h *HTMX) RequireTarget(allowed ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if targetAllowed(h.HxHeader(r).HxTarget, allowed) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			handler, ok := FromContext(r.Context())
+			if !ok {
+				handler = h.newHandler(w, r)
+				defer handler.Release()
+			}
+
+			handler.Error(http.StatusBadRequest, "Invalid target",
+				fmt.Errorf("htmx: target %q is not allowed", handler.Target()))
+		})
+	}
+}
+
+// UseCurrentURLPath passes htmx requests to next with the path of their HX-Current-URL header, parsed with
+// Handler.CurrentURLParsed, instead of the request path, so routers downstream see the logical page of SPA-like
+// setups. It only does so when the instance was created with WithCurrentURLRouting, and only for a current URL
+// on the host of the request; other requests, and a missing or malformed header, keep their path. The request
+// is copied, r.URL of the caller is unchanged, and HX-Current-URL is added to Vary.
+// The header is under the control of the client: any path can be requested this way, exactly like with the
+// request path itself, so every route must authorize the request on its own. Do not place it behind checks
+// made on the original path, like a prefix reserved to admins, which the rewritten path would sidestep.
+func (h *HTMX) UseCurrentURLPath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.currentURLPath || !h.IsHxRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		addVary(w.Header(), h.requestPrefix+HxRequestHeaderCurrentURL.String())
+
+		raw := h.HxHeader(r).HxCurrentURL
+		if raw == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		current, err := url.Parse(raw)
+		if err != nil || current.Path == "" || (current.Host != "" && current.Host != r.Host) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.log.Debug("htmx: routing on the current url", "path", r.URL.Path, "current", current.Path)
+
+		u := *r.URL
+		u.Path, u.RawPath = current.Path, current.RawPath
+
+		rewritten := r.WithContext(r.Context())
+		rewritten.URL = &u
+		next.ServeHTTP(w, rewritten)
+	})
+}
+
+// RequireFormContentType only lets unsafe requests with a form body through, see RequireContentType.
+func (h *HTMX) RequireFormContentType(next http.Handler) http.Handler {
+	return h.RequireContentType("application/x-www-form-urlencoded", "multipart/form-data")(next)
+}
+
+// RequireContentType rejects unsafe requests whose body has another media type than the accepted ones with
+// 415 Unsupported Media Type, so a JSON post does not silently parse as an empty form. htmx requests get the
+// response of Handler.Error, retargeted to the error target of the htmx instance, other requests a plain 415.
+// Requests without a body are let through.
+func (h *HTMX) RequireContentType(accepted ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType := r.Header.Get("Content-Type")
+			if isSafeMethod(r.Method) || (contentType == "" && r.ContentLength == 0) || acceptsMediaType(contentType, accepted) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !h.IsHxRequest(r) {
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+
+			handler, ok := FromContext(r.Context())
+			if !ok {
+				handler = h.newHandler(w, r)
+				defer handler.Release()
+			}
+
+			handler.Error(http.StatusUnsupportedMediaType, "Unsupported content type",
+				fmt.Errorf("htmx: unsupported content type %q", contentType))
+		})
+	}
+}
+
+// acceptsMediaType returns true when the media type of the Content-Type value is one of the accepted ones.
+func acceptsMediaType(contentType string, accepted []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range accepted {
+		if strings.EqualFold(mediaType, a) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,307 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	h := New()
+
+	var found bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var handler *Handler
+		handler, found = FromContext(r.Context())
+		if !found {
+			return
+		}
+
+		equalBool(t, true, handler.IsHxRequest())
+		handler.ReTarget(reTarget)
+		handler.JustWriteString("hi")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HxRequestHeaderRequest.String(), "true")
+	rec := httptest.NewRecorder()
+
+	h.Middleware(next).ServeHTTP(rec, r)
+
+	equalBool(t, true, found)
+	equal(t, "HX-Request", rec.Header().Get("Vary"))
+	equal(t, reTarget, rec.Header().Get(HXRetarget.String()))
+	equal(t, "hi", rec.Body.String())
+}
+
+func TestMiddlewareCommitsStagedHeaders(t *testing.T) {
+	setters := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, _ := FromContext(r.Context())
+		handler.Redirect("/login")
+		handler.Refresh(true)
+	}))
+
+	rec := httptest.NewRecorder()
+	setters.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "/login", rec.Header().Get(HXRedirect.String()))
+
+	logout := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, _ := FromContext(r.Context())
+		handler.Refresh(true)
+	}))
+
+	rec = httptest.NewRecorder()
+	logout.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/logout", nil))
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "true", rec.Header().Get(HXRefresh.String()))
+
+	direct := New().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, _ := FromContext(r.Context())
+		handler.ReTarget("#list").Status(http.StatusAccepted)
+
+		_, _ = w.Write([]byte("direct"))
+	}))
+
+	rec = httptest.NewRecorder()
+	direct.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	equalInt(t, http.StatusAccepted, rec.Code)
+	equal(t, "#list", rec.Header().Get(HXRetarget.String()))
+	equal(t, "direct", rec.Body.String())
+}
+
+func TestFromContextWithoutMiddleware(t *testing.T) {
+	_, ok := FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	equalBool(t, false, ok)
+}
+
+func TestRequireHxRequest(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		allowBoosted bool
+		status       int
+	}{
+		{name: "full page", status: http.StatusBadRequest},
+		{name: "htmx", headers: map[string]string{"HX-Request": "true"}, status: http.StatusOK},
+		{name: "boosted", headers: map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, status: http.StatusBadRequest},
+		{name: "boosted allowed", headers: map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, allowBoosted: true, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/partial", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireHxRequest(nil, tt.allowBoosted)(next).ServeHTTP(rec, r)
+
+			equalInt(t, tt.status, rec.Code)
+			equal(t, "HX-Request", rec.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	var (
+		mode     Mode
+		boosted  bool
+		id, name string
+	)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode = RenderModeFromContext(r.Context())
+		boosted = BoostedFromContext(r.Context())
+		id, name = TriggerFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Boosted", "true")
+	r.Header.Set("HX-Trigger", "nav-link")
+	r.Header.Set("HX-Trigger-Name", "nav")
+
+	New().Annotate(next).ServeHTTP(httptest.NewRecorder(), r)
+
+	equal(t, ModeBoosted.String(), mode.String())
+	equalBool(t, true, boosted)
+	equal(t, "nav-link", id)
+	equal(t, "nav", name)
+
+	next.ServeHTTP(httptest.NewRecorder(), r)
+
+	equal(t, ModeFull.String(), mode.String())
+	equalBool(t, false, boosted)
+	equal(t, "", id)
+	equal(t, "", name)
+}
+
+func TestMiddlewareSkipPaths(t *testing.T) {
+	var found bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, found = FromContext(r.Context())
+	})
+	mw := New(WithSkipPaths("/healthz", "/metrics")).Middleware(next)
+
+	rec := httptest.NewRecorder()
+	mw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/requests", nil))
+	equalBool(t, false, found)
+	equal(t, "", rec.Header().Get("Vary"))
+
+	rec = httptest.NewRecorder()
+	mw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pokemon", nil))
+	equalBool(t, true, found)
+	equal(t, "HX-Request", rec.Header().Get("Vary"))
+}
+
+func TestRequireFormContentType(t *testing.T) {
+	next := New().RequireFormContentType(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		hx          bool
+		code        int
+		target      string
+	}{
+		{"form", http.MethodPost, "application/x-www-form-urlencoded", true, http.StatusNoContent, ""},
+		{"multipart", http.MethodPut, "multipart/form-data; boundary=x", true, http.StatusNoContent, ""},
+		{"get", http.MethodGet, "application/json", true, http.StatusNoContent, ""},
+		{"json", http.MethodPost, "application/json", true, http.StatusUnsupportedMediaType, DefaultErrorTarget},
+		{"plain json", http.MethodPost, "application/json", false, http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", strings.NewReader("a=b"))
+			r.Header.Set("Content-Type", tt.contentType)
+			if tt.hx {
+				r.Header.Set("HX-Request", "true")
+			}
+
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+
+			equalInt(t, tt.code, rec.Code)
+			equal(t, tt.target, rec.Header().Get(HXRetarget.String()))
+		})
+	}
+}
+
+func TestRequireContentTypeInstanceSettings(t *testing.T) {
+	next := New(WithErrorTarget("#toast"), WithNotificationKey("toast")).RequireContentType("application/json")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=b"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, r)
+
+	equalInt(t, http.StatusUnsupportedMediaType, rec.Code)
+	equal(t, "#toast", rec.Header().Get(HXRetarget.String()))
+	equalBool(t, true, strings.HasPrefix(rec.Header().Get(HXTrigger.String()), `{"toast":`))
+}
+
+func TestRequireTarget(t *testing.T) {
+	next := New(WithErrorTarget("#alerts")).RequireTarget("#list", "detail")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name     string
+		target   string
+		code     int
+		retarget string
+	}{
+		{"selector", "list", http.StatusNoContent, ""},
+		{"id", "detail", http.StatusNoContent, ""},
+		{"no target", "", http.StatusNoContent, ""},
+		{"unknown", "admin", http.StatusBadRequest, "#alerts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("HX-Request", "true")
+			if tt.target != "" {
+				r.Header.Set("HX-Target", tt.target)
+			}
+
+			handler := New().NewHandler(httptest.NewRecorder(), r)
+			equalBool(t, tt.code == http.StatusNoContent, handler.TargetAllowed("#list", "detail"))
+
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+
+			equalInt(t, tt.code, rec.Code)
+			equal(t, tt.retarget, rec.Header().Get(HXRetarget.String()))
+		})
+	}
+}
+
+func TestUseCurrentURLPath(t *testing.T) {
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { seen = r.URL.Path })
+
+	serve := func(h *HTMX, hx bool, current string) string {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/fragment", nil)
+		if hx {
+			r.Header.Set(HxRequestHeaderRequest.String(), "true")
+		}
+		r.Header.Set(HxRequestHeaderCurrentURL.String(), current)
+
+		h.UseCurrentURLPath(next).ServeHTTP(httptest.NewRecorder(), r)
+		equal(t, "/fragment", r.URL.Path)
+		return seen
+	}
+
+	enabled := New(WithCurrentURLRouting())
+	equal(t, "/users/1", serve(enabled, true, "http://example.com/users/1?tab=posts"))
+	equal(t, "/fragment", serve(enabled, false, "http://example.com/users/1"))
+	equal(t, "/fragment", serve(enabled, true, "http://evil.example/users/1"))
+	equal(t, "/fragment", serve(enabled, true, "%zz"))
+	equal(t, "/fragment", serve(New(), true, "http://example.com/users/1"))
+}
+
+func TestHandlerFunc(t *testing.T) {
+	failed := errors.New("database down")
+	var handled error
+	h := New(WithErrorHandler(func(handler *Handler, err error) {
+		handled = err
+		handler.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.HandlerFunc(func(*Handler) error { return failed })(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	equalBool(t, true, errors.Is(handled, failed))
+	equalInt(t, http.StatusServiceUnavailable, rec.Code)
+
+	handled = nil
+	rec = httptest.NewRecorder()
+	h.HandlerFunc(func(handler *Handler) error {
+		_, err := handler.WriteString("ok")
+		return err
+	})(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	equalBool(t, true, handled == nil)
+	equal(t, "ok", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	New().HandlerFunc(func(*Handler) error { return failed })(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	equalInt(t, http.StatusInternalServerError, rec.Code)
+	equal(t, "#error", rec.Header().Get(HXRetarget.String()))
+}
@@ -0,0 +1,18 @@
+package htmx
+
+type (
+	// Observer is notified about the traffic of a htmx instance, e.g. to export metrics, see WithObserver.
+	// The callbacks run on the request goroutine and must be safe for concurrent use.
+	Observer interface {
+		// OnRequest is called when a handler is created for a request.
+		OnRequest(mode Mode)
+		// OnResponse is called when a handler commits its response.
+		OnResponse(status int)
+	}
+
+	// noopObserver ignores everything, it is the default observer of a new htmx instance.
+	noopObserver struct{}
+)
+
+func (noopObserver) OnRequest(Mode) {}
+func (noopObserver) OnResponse(int) {}
@@ -0,0 +1,93 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordObserver records the observed modes and status codes
+type recordObserver struct {
+	mu       sync.Mutex
+	modes    []Mode
+	statuses []int
+}
+
+func (o *recordObserver) OnRequest(mode Mode) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.modes = append(o.modes, mode)
+}
+
+func (o *recordObserver) OnResponse(status int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.statuses = append(o.statuses, status)
+}
+
+func TestObserver(t *testing.T) {
+	o := &recordObserver{}
+	h := New(WithObserver(o))
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, _ := FromContext(r.Context())
+		if handler.RenderPartial() {
+			handler.JustWriteString("partial")
+			return
+		}
+		handler.WriteHeader(http.StatusNotFound)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+	h.Middleware(next).ServeHTTP(httptest.NewRecorder(), r)
+	h.Middleware(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	equalInt(t, 2, len(o.modes))
+	equal(t, ModePartial.String(), o.modes[0].String())
+	equal(t, ModeFull.String(), o.modes[1].String())
+	equalInt(t, 2, len(o.statuses))
+	equalInt(t, http.StatusOK, o.statuses[0])
+	equalInt(t, http.StatusNotFound, o.statuses[1])
+}
+
+func TestObserverInternalHandlers(t *testing.T) {
+	o := &recordObserver{}
+	h := New(WithObserver(o), WithCurrentURLRouting())
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		code    int
+	}{
+		{"current url path", h.Middleware(h.UseCurrentURLPath(ok)), http.StatusOK},
+		{"require target", h.Middleware(h.RequireTarget("list")(ok)), http.StatusBadRequest},
+		{"require content type", h.Middleware(h.RequireFormContentType(ok)), http.StatusUnsupportedMediaType},
+		{"max body bytes", h.Middleware(h.MaxBodyBytes(4, nil)(ok)), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o.modes, o.statuses = nil, nil
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"pikachu"}`))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("HX-Request", "true")
+			r.Header.Set("HX-Target", "details")
+			r.Header.Set("HX-Current-URL", "http://example.com/pokemon")
+
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, r)
+
+			equalInt(t, tt.code, rec.Code)
+			equalInt(t, 1, len(o.modes))
+			equalInt(t, 1, len(o.statuses))
+		})
+	}
+}
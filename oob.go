@@ -0,0 +1,142 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// ErrOOBCollision is returned by OOB.Write when two out of band fragments replace the same element and
+// WithOOBCollision is set to OOBCollisionError.
+var ErrOOBCollision = errors.New("htmx: out of band fragments replace the same element")
+
+const (
+	// OOBCollisionWarn logs the out of band fragments replacing an element already replaced, the default.
+	OOBCollisionWarn OOBCollision = iota
+	// OOBCollisionError makes OOB.Write return ErrOOBCollision without writing anything.
+	OOBCollisionError
+)
+
+type (
+	// OOBCollision tells what OOB.Write does when two fragments replace the same element, see WithOOBCollision.
+	OOBCollision int
+
+	// OOB collects out of band fragments, htmx swaps them into the page next to the main content.
+	// https://htmx.org/attributes/hx-swap-oob/
+	OOB struct {
+		fragments []oobFragment
+		log       Logger
+		collision OOBCollision
+	}
+
+	oobFragment struct {
+		id   string
+		swap string
+		html template.HTML
+		// target is the selector of the element the fragment replaces, empty when it inserts content
+		target string
+	}
+)
+
+// OOB returns the out of band swap builder of the handler.
+func (h *Handler) OOB() *OOB {
+	if h.oob == nil {
+		h.oob = &OOB{log: h.log, collision: h.oobCollision}
+	}
+
+	return h.oob
+}
+
+// Add adds a fragment that replaces the element with the given id.
+// The fragment is wrapped in a div carrying the id and hx-swap-oob="true", which becomes the new element,
+// use AddSwap with SwapInnerHTML to keep the existing element.
+func (o *OOB) Add(id string, html template.HTML) *OOB {
+	o.fragments = append(o.fragments, oobFragment{id: id, swap: "true", html: html, target: "#" + id})
+	return o
+}
+
+// set adds a fragment like Add, replacing the fragment already added for the id.
+func (o *OOB) set(id string, html template.HTML) *OOB {
+	for i, f := range o.fragments {
+		if f.id == id {
+			o.fragments[i].html = html
+			return o
+		}
+	}
+
+	return o.Add(id, html)
+}
+
+// AddSwap adds a fragment that is swapped into the element matching the selector using the given style,
+// for example AddSwap(SwapBeforeEnd, "#list", html) renders hx-swap-oob="beforeend:#list".
+// For every style except outerHTML htmx swaps the children of the wrapping div, not the div itself.
+func (o *OOB) AddSwap(style SwapStyle, selector string, html template.HTML) *OOB {
+	swap := style.String()
+	if selector != "" {
+		swap += ":" + selector
+	}
+
+	f := oobFragment{swap: swap, html: html}
+	if selector != "" && (style == SwapOuterHTML || style == SwapInnerHTML) {
+		f.target = selector
+	}
+
+	o.fragments = append(o.fragments, f)
+	return o
+}
+
+// Write writes the main content followed by the out of band fragments, as htmx expects, and resets the builder.
+// Two fragments replacing the same element, by id with Add or by selector with AddSwap and outerHTML or innerHTML,
+// are a collision: only the last one shows. Collisions are logged, or returned as ErrOOBCollision without writing
+// anything, see WithOOBCollision. Fragments inserting content, like beforeend, never collide.
+func (o *OOB) Write(w io.Writer, main template.HTML) (int, error) {
+	if err := o.checkCollisions(); err != nil {
+		o.fragments = nil
+		return 0, err
+	}
+
+	var b strings.Builder
+
+	b.WriteString(string(main))
+
+	for _, f := range o.fragments {
+		b.WriteString("<div")
+		if f.id != "" {
+			b.WriteString(` id="` + template.HTMLEscapeString(f.id) + `"`)
+		}
+		b.WriteString(` hx-swap-oob="` + template.HTMLEscapeString(f.swap) + `">`)
+		b.WriteString(string(f.html))
+		b.WriteString("</div>")
+	}
+
+	o.fragments = nil
+
+	return io.WriteString(w, b.String())
+}
+
+// checkCollisions reports the fragments replacing an element replaced by an earlier fragment.
+func (o *OOB) checkCollisions() error {
+	seen := make(map[string]bool, len(o.fragments))
+
+	for _, f := range o.fragments {
+		if f.target == "" {
+			continue
+		}
+		if !seen[f.target] {
+			seen[f.target] = true
+			continue
+		}
+
+		if o.collision == OOBCollisionError {
+			return fmt.Errorf("%w: %s", ErrOOBCollision, f.target)
+		}
+		if o.log != nil {
+			o.log.Warn("htmx: out of band fragments replace the same element, only the last one shows",
+				"target", f.target)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOOB(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.OOB().
+		Add("sidebar", "<p>3 items</p>").
+		AddSwap(SwapBeforeEnd, "#list", "<li>new</li>")
+
+	_, err := handler.OOB().Write(handler, "<p>main</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<p>main</p><div id="sidebar" hx-swap-oob="true"><p>3 items</p></div><div hx-swap-oob="beforeend:#list"><li>new</li></div>`
+	equal(t, expected, rec.Body.String())
+	equalInt(t, 0, len(handler.OOB().fragments))
+}
+
+func TestOOBCollision(t *testing.T) {
+	log := &recordLogger{}
+	rec := httptest.NewRecorder()
+	handler := New(WithLogger(log)).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.OOB().
+		AddSwap(SwapBeforeEnd, "#log", "<li>a</li>").
+		AddSwap(SwapBeforeEnd, "#log", "<li>b</li>").
+		Add("list", "<ul>first</ul>").
+		AddSwap(SwapInnerHTML, "#list", "<li>second</li>")
+
+	if _, err := handler.OOB().Write(handler, "<p>main</p>"); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "warn: htmx: out of band fragments replace the same element, only the last one shows", strings.Join(log.entries, "\n"))
+	equalBool(t, true, strings.Contains(rec.Body.String(), "second"))
+
+	rec = httptest.NewRecorder()
+	handler = New(WithOOBCollision(OOBCollisionError)).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.OOB().Add("list", "<ul>first</ul>").Add("list", "<ul>second</ul>")
+
+	_, err := handler.OOB().Write(handler, "<p>main</p>")
+	equalBool(t, true, errors.Is(err, ErrOOBCollision))
+	equalInt(t, 0, rec.Body.Len())
+	equalInt(t, 0, len(handler.OOB().fragments))
+}
@@ -0,0 +1,335 @@
+package htmx
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Option configures a htmx instance, see New.
+type Option func(*HTMX)
+
+// WithLogger sets the logger used by the htmx instance and its handlers, nil disables logging.
+func WithLogger(log Logger) Option {
+	return func(h *HTMX) {
+		h.SetLog(log)
+	}
+}
+
+// WithNoLog disables logging, which is the default, e.g. to undo a logger set by an earlier option.
+func WithNoLog() Option {
+	return WithLogger(nil)
+}
+
+// WithObserver sets the observer notified about every request and response of the htmx instance,
+// nil disables it. The github.com/developersismedika/go-htmx/prometheus module exports the calls as metrics.
+func WithObserver(o Observer) Option {
+	return func(h *HTMX) {
+		if o == nil {
+			o = noopObserver{}
+		}
+
+		h.observer = o
+	}
+}
+
+// WithSwapDuration overrides DefaultSwapDuration for the htmx instance.
+func WithSwapDuration(d time.Duration) Option {
+	return func(h *HTMX) {
+		h.swapDuration = d
+	}
+}
+
+// WithSettleDelay overrides DefaultSettleDelay for the htmx instance.
+func WithSettleDelay(d time.Duration) Option {
+	return func(h *HTMX) {
+		h.settleDelay = d
+	}
+}
+
+// WithNotificationKey overrides DefaultNotificationKey, the event name used by the notification triggers.
+func WithNotificationKey(key string) Option {
+	return func(h *HTMX) {
+		h.notificationKey = key
+	}
+}
+
+// WithNotificationSchema sets the detail of the notification event, so it matches the contract of the front-end,
+// e.g. {"type": level, "text": message}. The default is {"level": level, "message": message}, nil restores it.
+// The vars of the notification triggers are merged when schema returns a map[string]any.
+func WithNotificationSchema(schema func(level, message string) any) Option {
+	return func(h *HTMX) {
+		if schema == nil {
+			schema = defaultNotificationSchema
+		}
+
+		h.notificationSchema = schema
+	}
+}
+
+// WithErrorTarget overrides DefaultErrorTarget, the selector of the element error responses are retargeted to.
+func WithErrorTarget(selector string) Option {
+	return func(h *HTMX) {
+		h.errorTarget = selector
+	}
+}
+
+// WithEventDetailHeader overrides DefaultEventDetailHeader, the request header Handler.TriggerDetail decodes.
+func WithEventDetailHeader(name string) Option {
+	return func(h *HTMX) {
+		h.detailHeader = name
+	}
+}
+
+// WithClientInfoHeader overrides DefaultClientInfoHeader, the request header Handler.ClientInfo parses.
+func WithClientInfoHeader(name string) Option {
+	return func(h *HTMX) {
+		h.clientHeader = name
+	}
+}
+
+// WithFormMaxBytes overrides DefaultFormMaxBytes, the size of the request body Handler.FormValue and
+// Handler.DecodeForm parse at most.
+func WithFormMaxBytes(n int64) Option {
+	return func(h *HTMX) {
+		h.formMaxBytes = n
+	}
+}
+
+// WithFragmentHeader overrides DefaultFragmentHeader, the response header Handler.MarkFragment sets, an empty
+// name disables it.
+func WithFragmentHeader(name string) Option {
+	return func(h *HTMX) {
+		h.fragmentHeader = name
+	}
+}
+
+// WithPartialNoStore makes Render and the RenderNamed helpers send Cache-Control: no-store on their partial and
+// boosted branches, so the browser cache and the bfcache never serve a stale fragment. Full pages are left alone,
+// they may be cacheable. It composes with MarkFragment and the Vary helpers.
+func WithPartialNoStore() Option {
+	return func(h *HTMX) {
+		h.partialNoStore = true
+	}
+}
+
+// WithOOBCollision sets what OOB.Write does when two out of band fragments replace the same element:
+// OOBCollisionWarn, the default, logs it and OOBCollisionError returns ErrOOBCollision.
+func WithOOBCollision(c OOBCollision) Option {
+	return func(h *HTMX) {
+		h.oobCollision = c
+	}
+}
+
+// WithRenderModeHeader makes Render and the RenderNamed helpers send the Mode they rendered, "full", "boosted"
+// or "partial", in the RenderModeHeader response header, to follow their choice in the browser devtools.
+// It is meant for development, leave it off in production.
+func WithRenderModeHeader() Option {
+	return func(h *HTMX) {
+		h.renderModeDebug = true
+	}
+}
+
+// WithBoostedFragments makes Handler.FragmentOrPage serve the fragment to boosted requests too, for fragment
+// endpoints whose fragment is a whole page body.
+func WithBoostedFragments() Option {
+	return func(h *HTMX) {
+		h.boostedFragments = true
+	}
+}
+
+// WithPreloadHeader overrides DefaultPreloadHeader, the request header marking the speculative requests of the
+// preload extension, see Handler.IsPreload.
+func WithPreloadHeader(name string) Option {
+	return func(h *HTMX) {
+		h.preloadHeader = name
+	}
+}
+
+// WithFragmentCache replaces the cache of Handler.CachedFragment, an LRUFragmentCache of
+// DefaultFragmentCacheSize fragments, e.g. with a larger one. nil disables the cache, fragments always render.
+func WithFragmentCache(cache FragmentCache) Option {
+	return func(h *HTMX) {
+		h.fragmentCache = cache
+	}
+}
+
+// WithFragmentCacheHtmxOnly makes Handler.CachedFragment bypass the cache for requests other than htmx requests,
+// e.g. when full pages embed the fragment with data for the signed in user.
+func WithFragmentCacheHtmxOnly() Option {
+	return func(h *HTMX) {
+		h.fragmentCacheHxOnly = true
+	}
+}
+
+// WithClock sets the clock of the time dependent features, e.g. a fake clock in tests, see htmxtest.FakeClock.
+// nil restores the real clock.
+func WithClock(c Clock) Option {
+	return func(h *HTMX) {
+		if c == nil {
+			c = realClock{}
+		}
+
+		h.clock = c
+	}
+}
+
+// WithCurrentURLRouting lets HTMX.UseCurrentURLPath route htmx requests on the path of their HX-Current-URL
+// header, it is off by default. The header is sent by the client, read the security notes of UseCurrentURLPath.
+func WithCurrentURLRouting() Option {
+	return func(h *HTMX) {
+		h.currentURLPath = true
+	}
+}
+
+// WithRequestHeaderPrefix makes the instance read the htmx request headers under names starting with prefix, e.g.
+// "X-Forwarded-" for a gateway forwarding HX-Request as X-Forwarded-HX-Request. It applies to the handler
+// accessors, the instance methods like HTMX.IsHxRequest and the middleware of the instance. The package level
+// functions, like IsHxRequest, keep reading the plain names. There is no prefix by default.
+func WithRequestHeaderPrefix(prefix string) Option {
+	return func(h *HTMX) {
+		h.requestPrefix = prefix
+	}
+}
+
+// WithErrorHandler sets the function HTMX.HandlerFunc passes the errors of its handlers to, nil restores the
+// default, which responds with Error.
+func WithErrorHandler(fn func(*Handler, error)) Option {
+	return func(h *HTMX) {
+		if fn == nil {
+			fn = defaultErrorHandler
+		}
+
+		h.errorHandler = fn
+	}
+}
+
+// WithRecoverView sets the response HTMX.Recover writes for a recovered panic, e.g. an error page of the app.
+// fn gets the Handler of the request, without the htmx headers staged before the panic, and the recovered value,
+// use Handler.IsHxRequest to tell htmx requests apart. nil restores the default, see HTMX.Recover.
+func WithRecoverView(fn func(h *Handler, recovered any)) Option {
+	return func(h *HTMX) {
+		if fn == nil {
+			fn = defaultRecoverView
+		}
+
+		h.recoverView = fn
+	}
+}
+
+// WithFragmentFilters adds filters every Handler.Write runs its data through, in order, e.g. to add CSP nonces or
+// rewrite asset urls. A filter sees each write on its own: a template writes its output in many small chunks,
+// so a filter must not rely on a match falling within one chunk, or the handler should write the body at once,
+// e.g. with RenderOrAbort.
+func WithFragmentFilters(filters ...FragmentFilter) Option {
+	return func(h *HTMX) {
+		h.filters = append(h.filters, filters...)
+	}
+}
+
+// WithSSETriggerEvent overrides DefaultSSETriggerEvent, the name of the server sent event carrying triggered
+// events, see SSEWriter.SendTrigger.
+func WithSSETriggerEvent(name string) Option {
+	return func(h *HTMX) {
+		h.sseTriggerEvent = name
+	}
+}
+
+// WithIdempotencyHeader overrides DefaultIdempotencyHeader, the request header carrying the idempotency key,
+// see HTMX.Idempotent.
+func WithIdempotencyHeader(name string) Option {
+	return func(h *HTMX) {
+		h.idempotencyHeader = name
+	}
+}
+
+// WithIdempotencyTTL overrides DefaultIdempotencyTTL, how long HTMX.Idempotent replays a response.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(h *HTMX) {
+		h.idempotencyTTL = ttl
+	}
+}
+
+// WithSkipPaths makes the Middleware call the next handler directly for request paths starting with one of the
+// prefixes, health checks and metrics endpoints then get neither a Handler nor the Vary header.
+// FromContext reports false on those paths.
+func WithSkipPaths(prefixes ...string) Option {
+	return func(h *HTMX) {
+		h.skipPaths = append(h.skipPaths, prefixes...)
+	}
+}
+
+// WithConfirmHeader overrides DefaultConfirmHeader, the request header marking a request as confirmed,
+// see Handler.RequireConfirm.
+func WithConfirmHeader(name string) Option {
+	return func(h *HTMX) {
+		h.confirmHeader = name
+	}
+}
+
+// WithHandlerPool recycles handlers through a sync.Pool, which lowers allocations under load.
+// Every handler must then be released with Handler.Release once the request is done, the Middleware does so
+// after the next handler returns. A released handler must not be used anymore.
+func WithHandlerPool() Option {
+	return func(h *HTMX) {
+		h.pool = &sync.Pool{
+			New: func() any {
+				return &Handler{}
+			},
+		}
+	}
+}
+
+// WithOrderedTriggers keeps the events of the trigger headers carrying details in the order they were added, the
+// client dispatches them in that order, instead of sorting them by name as encoding a map does. The trigger encoder,
+// see WithTriggerEncoder, then receives the details one by one. Events without details are always sent in order.
+func WithOrderedTriggers() Option {
+	return func(h *HTMX) {
+		h.orderedTriggers = true
+	}
+}
+
+// WithTriggerEncoder replaces encoding/json for the HX-Trigger headers of handlers whose events carry details,
+// e.g. to escape HTML in user provided details or to use a faster marshaler. The encoder receives a map of event
+// names to details, or each detail on its own with WithOrderedTriggers. nil restores json.Marshal.
+func WithTriggerEncoder(enc func(any) ([]byte, error)) Option {
+	return func(h *HTMX) {
+		if enc == nil {
+			enc = json.Marshal
+		}
+
+		h.triggerEncoder = enc
+	}
+}
+
+// WithTriggerSizeLimit sets the size, in bytes, above which a trigger header is logged as a warning,
+// DefaultTriggerSizeLimit by default, 0 disables the check. A non nil overflow replaces the value of such a
+// header, e.g. TriggerOverflowOOB, otherwise the header is sent as is.
+func WithTriggerSizeLimit(limit int, overflow TriggerOverflow) Option {
+	return func(h *HTMX) {
+		h.triggerLimit = limit
+		h.triggerOverflow = overflow
+	}
+}
+
+// WithDefaultTriggerTiming sets the header Handler.TriggerWithObject and the notification triggers use:
+// HXTrigger, the default, fires the events as soon as the response is received, HXTriggerAfterSwap after the
+// swap and HXTriggerAfterSettle once the DOM settled. Other keys are ignored.
+// TriggerNowWithObject, TriggerAfterSwapWithObject and TriggerAfterSettleWithObject keep their own timing.
+func WithDefaultTriggerTiming(timing HxResponseKey) Option {
+	return func(h *HTMX) {
+		switch timing {
+		case HXTrigger, HXTriggerAfterSwap, HXTriggerAfterSettle:
+			h.triggerTiming = timing
+		}
+	}
+}
+
+// WithCompression gzip encodes response bodies for clients sending Accept-Encoding: gzip.
+// Handlers must be closed with Handler.Close or Release once the body is written, the Middleware does so.
+// Server sent event streams are never compressed.
+func WithCompression() Option {
+	return func(h *HTMX) {
+		h.compression = true
+	}
+}
@@ -0,0 +1,106 @@
+package htmx
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// PollLimitOption configures PollLimit.
+	PollLimitOption func(*pollLimiter)
+
+	pollLimiter struct {
+		htmx        *HTMX
+		minInterval time.Duration
+		status      int
+		key         func(*http.Request) string
+		now         func() time.Time
+
+		mu        sync.Mutex
+		seen      map[string]time.Time
+		lastSweep time.Time
+	}
+)
+
+// WithPollStatus sets the status PollLimit rejects requests with, StatusStopPolling by default,
+// which stops the polling element. http.StatusTooManyRequests leaves it polling.
+func WithPollStatus(code int) PollLimitOption {
+	return func(l *pollLimiter) {
+		l.status = code
+	}
+}
+
+// WithPollKey sets the function identifying the client, the host of the remote address by default.
+func WithPollKey(key func(*http.Request) string) PollLimitOption {
+	return func(l *pollLimiter) {
+		l.key = key
+	}
+}
+
+// PollLimit rejects htmx requests for a path arriving from the same client faster than minInterval,
+// protecting endpoints from runaway hx-trigger="every ..." polls. Other requests are never limited.
+// A rejected request does not count, the client may retry once minInterval has passed since the last accepted one.
+func (h *HTMX) PollLimit(minInterval time.Duration, opts ...PollLimitOption) func(http.Handler) http.Handler {
+	l := &pollLimiter{
+		htmx:        h,
+		minInterval: minInterval,
+		status:      StatusStopPolling,
+		key:         remoteHost,
+		now:         h.clock.Now,
+		seen:        make(map[string]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l.middleware
+}
+
+func (l *pollLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.htmx.IsHxRequest(r) || l.allow(l.key(r)+" "+r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		l.htmx.log.Debug("htmx: poll limit exceeded", "path", r.URL.Path, "status", l.status)
+		w.WriteHeader(l.status)
+	})
+}
+
+// allow records the request for key unless it came too early.
+func (l *pollLimiter) allow(key string) bool {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.minInterval {
+		for k, seen := range l.seen {
+			if now.Sub(seen) >= l.minInterval {
+				delete(l.seen, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	if seen, ok := l.seen[key]; ok && now.Sub(seen) < l.minInterval {
+		return false
+	}
+
+	l.seen[key] = now
+	return true
+}
+
+// remoteHost returns the host of the remote address, or the whole address when it has no port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,64 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPollLimit(t *testing.T) {
+	now := time.Now()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		opts   []PollLimitOption
+		status int
+	}{
+		{"stop polling", nil, StatusStopPolling},
+		{"too many requests", []PollLimitOption{WithPollStatus(http.StatusTooManyRequests)}, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := New().PollLimit(time.Second, append(tt.opts, func(l *pollLimiter) {
+				l.now = func() time.Time { return now }
+			})...)(ok)
+
+			serve := func(path string, hx bool, offset time.Duration) int {
+				now = now.Add(offset)
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				if hx {
+					r.Header.Set("HX-Request", "true")
+				}
+
+				rec := httptest.NewRecorder()
+				limit.ServeHTTP(rec, r)
+				return rec.Code
+			}
+
+			equalInt(t, http.StatusOK, serve("/poll", true, 0))
+			equalInt(t, tt.status, serve("/poll", true, 500*time.Millisecond))
+			equalInt(t, http.StatusOK, serve("/other", true, 0))
+			equalInt(t, http.StatusOK, serve("/poll", false, 0))
+			equalInt(t, http.StatusOK, serve("/poll", true, 500*time.Millisecond))
+		})
+	}
+}
+
+func TestPollLimitKey(t *testing.T) {
+	limit := New().PollLimit(time.Hour, WithPollKey(func(r *http.Request) string {
+		return r.Header.Get("X-Session")
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, session := range []string{"a", "b"} {
+		r := httptest.NewRequest(http.MethodGet, "/poll", nil)
+		r.Header.Set("HX-Request", "true")
+		r.Header.Set("X-Session", session)
+
+		rec := httptest.NewRecorder()
+		limit.ServeHTTP(rec, r)
+		equalInt(t, http.StatusOK, rec.Code)
+	}
+}
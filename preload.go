@@ -0,0 +1,31 @@
+package htmx
+
+import (
+	"context"
+	"net/http"
+)
+
+// SkipSideEffectsOnPreload marks the speculative requests of the htmx preload extension in their context, see
+// Handler.IsPreload. A request is still served so the extension can cache the response, handlers guard their
+// side effects with SideEffectsAllowed:
+//
+//	if htmx.SideEffectsAllowed(r.Context()) {
+//		notifications.MarkRead(id)
+//	}
+func (h *HTMX) SkipSideEffectsOnPreload(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !HxStrToBool(r.Header.Get(h.preloadHeader)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.log.Debug("htmx: preload request, skipping side effects", "path", r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), preloadContextKey, true)))
+	})
+}
+
+// SideEffectsAllowed returns false for requests SkipSideEffectsOnPreload marked as preloads, true otherwise.
+func SideEffectsAllowed(ctx context.Context) bool {
+	preload, _ := ctx.Value(preloadContextKey).(bool)
+	return !preload
+}
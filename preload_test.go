@@ -0,0 +1,34 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsPreload(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	equalBool(t, false, New().NewHandler(httptest.NewRecorder(), r).IsPreload())
+
+	r.Header.Set(DefaultPreloadHeader, "true")
+	equalBool(t, true, New().NewHandler(httptest.NewRecorder(), r).IsPreload())
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Prefetch", "true")
+	equalBool(t, true, New(WithPreloadHeader("X-Prefetch")).NewHandler(httptest.NewRecorder(), r).IsPreload())
+}
+
+func TestSkipSideEffectsOnPreload(t *testing.T) {
+	var allowed bool
+	next := New().SkipSideEffectsOnPreload(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed = SideEffectsAllowed(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/notifications/1", nil)
+	next.ServeHTTP(httptest.NewRecorder(), r)
+	equalBool(t, true, allowed)
+
+	r.Header.Set(DefaultPreloadHeader, "true")
+	next.ServeHTTP(httptest.NewRecorder(), r)
+	equalBool(t, false, allowed)
+}
@@ -0,0 +1,59 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownSwapPreset is returned by Handler.ReSwapPreset for a name no preset is registered under.
+var ErrUnknownSwapPreset = errors.New("htmx: unknown swap preset")
+
+var (
+	swapPresetsMu sync.RWMutex
+	swapPresets   = map[string]string{}
+)
+
+func init() {
+	_ = RegisterSwapPreset("fade", SwapInnerHTML, DefaultSwapDuration, DefaultSettleDelay, "transition:true")
+	_ = RegisterSwapPreset("slide", SwapOuterHTML, DefaultSwapDuration, DefaultSettleDelay, "transition:true", "show:top")
+}
+
+// RegisterSwapPreset registers the swap style, timings and modifiers, like "transition:true", under name, so the
+// transitions of a code base are configured in one place, see Handler.ReSwapPreset. Registering a name again
+// replaces the preset. The built-in "fade" and "slide" presets take DefaultSwapDuration and DefaultSettleDelay as
+// they are when the package is initialized. An invalid style or a negative timing returns an error.
+func RegisterSwapPreset(name string, style SwapStyle, swap, settle time.Duration, modifiers ...string) error {
+	if !style.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidSwapStyle, style)
+	}
+	if swap < 0 || settle < 0 {
+		return fmt.Errorf("htmx: negative timing for swap preset %q", name)
+	}
+
+	parts := append([]string{style.String()}, modifiers...)
+	parts = append(parts, newTiming(TimingSwap, 0, swap).String(), newTiming(TimingSettle, 0, settle).String())
+
+	swapPresetsMu.Lock()
+	defer swapPresetsMu.Unlock()
+
+	swapPresets[name] = strings.Join(parts, " ")
+	return nil
+}
+
+// ReSwapPreset sets HX-Reswap to the preset registered under name, see RegisterSwapPreset.
+// An unknown name returns ErrUnknownSwapPreset and no header is set.
+func (h *Handler) ReSwapPreset(name string) error {
+	swapPresetsMu.RLock()
+	val, ok := swapPresets[name]
+	swapPresetsMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownSwapPreset, name)
+	}
+
+	h.ReSwap(val)
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package prometheus exports the traffic of a htmx instance as Prometheus metrics.
+// It lives in its own module so the htmx package does not depend on the Prometheus client.
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/developersismedika/go-htmx"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Observer is a htmx.Observer counting requests by render mode and responses by status code.
+type Observer struct {
+	requests  *prom.CounterVec
+	responses *prom.CounterVec
+}
+
+var _ htmx.Observer = (*Observer)(nil)
+
+// New returns an Observer registered with reg, pass it to htmx.WithObserver.
+// It exports htmx_requests_total, labeled with the render mode (full, boosted or partial),
+// and htmx_responses_total, labeled with the status code.
+func New(reg prom.Registerer) (*Observer, error) {
+	o := &Observer{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Name: "htmx_requests_total",
+			Help: "Requests handled by htmx handlers, by render mode.",
+		}, []string{"mode"}),
+		responses: prom.NewCounterVec(prom.CounterOpts{
+			Name: "htmx_responses_total",
+			Help: "Responses committed by htmx handlers, by status code.",
+		}, []string{"code"}),
+	}
+
+	for _, c := range []prom.Collector{o.requests, o.responses} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// OnRequest counts the request under its render mode.
+func (o *Observer) OnRequest(mode htmx.Mode) {
+	o.requests.WithLabelValues(mode.String()).Inc()
+}
+
+// OnResponse counts the response under its status code.
+func (o *Observer) OnResponse(status int) {
+	o.responses.WithLabelValues(strconv.Itoa(status)).Inc()
+}
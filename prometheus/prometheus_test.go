@@ -0,0 +1,41 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developersismedika/go-htmx"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserver(t *testing.T) {
+	reg := prom.NewRegistry()
+
+	o, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := htmx.New(htmx.WithObserver(o))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+	h.NewHandler(httptest.NewRecorder(), r).JustWriteString("partial")
+	h.NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)).WriteHeader(http.StatusNotFound)
+
+	if got := testutil.ToFloat64(o.requests.WithLabelValues("partial")); got != 1 {
+		t.Errorf("expected 1 partial request, got %v", got)
+	}
+	if got := testutil.ToFloat64(o.requests.WithLabelValues("full")); got != 1 {
+		t.Errorf("expected 1 full request, got %v", got)
+	}
+	if got := testutil.ToFloat64(o.responses.WithLabelValues("404")); got != 1 {
+		t.Errorf("expected 1 not found response, got %v", got)
+	}
+
+	if _, err := New(reg); err == nil {
+		t.Error("expected an error registering the metrics twice")
+	}
+}
@@ -0,0 +1,83 @@
+package htmx
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// recoverWriter records whether next wrote anything before panicking, see HTMX.Recover.
+type recoverWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+// Recover recovers the panics of next, logs them with their stack through the logger of the instance and
+// responds with the recover view, see WithRecoverView, instead of dropping the connection. By default htmx
+// requests get Error with 500 Internal Server Error and DefaultErrorMessage, retargeted to the error container
+// with an error notification, other requests a plain 500 page. The htmx headers staged before the panic are
+// discarded. A panic after the response was committed is only logged, and http.ErrAbortHandler is re-raised
+// for the server to abort the response.
+// Place it after the Middleware, so the Handler of the request is neither released nor committed by the panic.
+func (h *HTMX) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoverWriter{ResponseWriter: w}
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			h.log.Error("htmx: recovered from a panic", "path", r.URL.Path, "panic", fmt.Sprint(p),
+				"stack", string(debug.Stack()))
+
+			handler, ok := FromContext(r.Context())
+			if !ok {
+				if rw.wrote {
+					return
+				}
+
+				handler = h.newHandler(w, r)
+				defer handler.Release()
+			}
+
+			if handler.isCommitted() {
+				return
+			}
+
+			handler.discardStaged()
+			h.recoverView(handler, p)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// defaultRecoverView is the recover view of HTMX.Recover without WithRecoverView.
+func defaultRecoverView(h *Handler, recovered any) {
+	if !h.IsHxRequest() {
+		http.Error(h, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	h.Error(http.StatusInternalServerError, DefaultErrorMessage, fmt.Errorf("htmx: panic: %v", recovered))
+}
+
+func (w *recoverWriter) WriteHeader(code int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recoverWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying response writer, see http.ResponseController.
+func (w *recoverWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,68 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	log := &recordLogger{}
+	h := New(WithLogger(log))
+
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := FromContext(r.Context()); ok {
+			handler.TriggerSuccess("saved")
+		}
+		panic("boom")
+	})
+
+	t.Run("htmx", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/save", nil)
+		r.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+
+		h.Middleware(h.Recover(panicking)).ServeHTTP(rec, r)
+
+		equalInt(t, http.StatusInternalServerError, rec.Code)
+		equal(t, DefaultErrorTarget, rec.Header().Get(HXRetarget.String()))
+		equalBool(t, true, strings.Contains(rec.Header().Get(HXTrigger.String()), `"level":"error"`))
+		equalBool(t, false, strings.Contains(rec.Header().Get(HXTrigger.String()), "saved"))
+		equal(t, "Something went wrong, please try again.", rec.Body.String())
+		equal(t, "error: htmx: recovered from a panic", log.entries[0])
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.Recover(panicking).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/save", nil))
+
+		equalInt(t, http.StatusInternalServerError, rec.Code)
+		equal(t, "Internal Server Error\n", rec.Body.String())
+		equal(t, "", rec.Header().Get(HXRetarget.String()))
+	})
+
+	t.Run("view", func(t *testing.T) {
+		view := New(WithRecoverView(func(h *Handler, recovered any) {
+			h.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = h.WriteString("down: " + recovered.(string))
+		}))
+
+		rec := httptest.NewRecorder()
+		view.Recover(panicking).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/save", nil))
+
+		equalInt(t, http.StatusServiceUnavailable, rec.Code)
+		equal(t, "down: boom", rec.Body.String())
+	})
+
+	t.Run("committed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("partial"))
+			panic("late")
+		})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/save", nil))
+
+		equalInt(t, http.StatusOK, rec.Code)
+		equal(t, "partial", rec.Body.String())
+	})
+}
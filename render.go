@@ -0,0 +1,142 @@
+package htmx
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Renderer renders the named template of a template engine, see Handler.RenderNamed.
+	Renderer interface {
+		Render(w io.Writer, name string, data any) error
+	}
+
+	templateRenderer struct {
+		t *template.Template
+	}
+)
+
+// NewTemplateRenderer returns a Renderer executing the named templates of t.
+func NewTemplateRenderer(t *template.Template) Renderer {
+	return templateRenderer{t: t}
+}
+
+// Render executes the named template.
+func (r templateRenderer) Render(w io.Writer, name string, data any) error {
+	return r.t.ExecuteTemplate(w, name, data)
+}
+
+// Render calls partial when the request should be rendered partially, see RenderPartial, and full otherwise.
+// The partial branch is marked as a fragment, see MarkFragment and WithPartialNoStore.
+// Both write to the handler, so the staged htmx response headers are committed with the first byte.
+// History restore requests always get the full render, htmx expects a complete page for them.
+func (h *Handler) Render(full, partial func(io.Writer) error) error {
+	if mode := h.RenderMode(); mode != ModeFull {
+		h.beforeRender(mode)
+		return partial(h)
+	}
+
+	h.beforeRender(ModeFull)
+	return full(h)
+}
+
+// FragmentOrPage serves a fragment endpoint that may also be visited directly, e.g. from a bookmark or a shared
+// link: htmx requests get the fragment, any other request the full page around it instead of a naked fragment.
+// Boosted navigations get the page as well, htmx swaps its body, unless the htmx instance has
+// WithBoostedFragments. History restores always get the page, see RenderPartial. The response depends on
+// HX-Request, which the Middleware adds to Vary.
+func (h *Handler) FragmentOrPage(fragment, page func(io.Writer) error) error {
+	if h.RenderPartial() && (h.boostedFragments || !h.Boosted()) {
+		h.beforeRender(h.RenderMode())
+		return fragment(h)
+	}
+
+	h.beforeRender(ModeFull)
+	return page(h)
+}
+
+// beforeRender prepares the response headers for the branch the render helpers picked, before anything is written.
+func (h *Handler) beforeRender(mode Mode) {
+	if h.renderModeDebug && !h.isCommitted() {
+		h.w.Header().Set(RenderModeHeader, mode.String())
+	}
+
+	if mode == ModeFull {
+		return
+	}
+
+	h.MarkFragment()
+	if h.partialNoStore && !h.isCommitted() {
+		h.w.Header().Set("Cache-Control", "no-store")
+	}
+}
+
+// RenderOrAbort renders into a buffer first and only writes it, committing the staged htmx response headers, when
+// render succeeds. On error the staged headers and triggered events are discarded, nothing is written and the
+// error is returned, so no success trigger reaches the client of a failed render and the caller can still respond
+// with an error, see Error.
+func (h *Handler) RenderOrAbort(render func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		h.discardStaged()
+		return err
+	}
+
+	_, err := h.Write(buf.Bytes())
+	return err
+}
+
+// ValidationErrors responds to a form failing validation: the status is 422 Unprocessable Entity, the form, the
+// element triggering the request as identified by HX-Trigger, is retargeted and swapped with outerHTML, and render
+// writes the form again with the field errors, keyed by field name, shown inline. Without HX-Trigger the target
+// of the request is kept. Like Error it relies on the client swapping 422 responses, which htmx does not do by
+// default, see the responseHandling config of htmx 2 or a htmx:beforeSwap listener.
+// Errors of render are returned unchanged, the response is committed with the first byte it writes.
+func (h *Handler) ValidationErrors(errs map[string]string, render func(io.Writer, map[string]string) error) error {
+	if id := h.TriggerID(); id != "" && !strings.HasPrefix(id, "{") {
+		h.ReTarget("#" + id)
+	}
+
+	h.ReSwapStyle(SwapOuterHTML).Status(http.StatusUnprocessableEntity)
+	h.w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	return render(h, errs)
+}
+
+// RenderTemplate executes the partialName template for partial requests and the fullName template otherwise,
+// see Render. Execution errors are returned unchanged.
+func (h *Handler) RenderTemplate(t *template.Template, fullName, partialName string, data any) error {
+	return h.RenderNamed(NewTemplateRenderer(t), fullName, partialName, data)
+}
+
+// RenderNamed renders partialName for partial and boosted requests and fullName otherwise with any template engine,
+// see Render. Errors of the renderer are returned unchanged.
+func (h *Handler) RenderNamed(renderer Renderer, fullName, partialName string, data any) error {
+	return h.RenderNamedBoosted(renderer, fullName, partialName, partialName, data)
+}
+
+// RenderTemplateBoosted is RenderTemplate with a separate template for boosted navigations, see RenderNamedBoosted.
+func (h *Handler) RenderTemplateBoosted(t *template.Template, fullName, boostedName, partialName string, data any) error {
+	return h.RenderNamedBoosted(NewTemplateRenderer(t), fullName, boostedName, partialName, data)
+}
+
+// RenderNamedBoosted is RenderNamed with a separate template for boosted navigations, see RenderMode.
+// boostedName usually renders the page content with the navigation but without the outer document,
+// partialName renders the fragment swapped by targeted requests and fullName the complete document.
+func (h *Handler) RenderNamedBoosted(renderer Renderer, fullName, boostedName, partialName string, data any) error {
+	name := fullName
+
+	mode := h.RenderMode()
+	switch mode {
+	case ModeBoosted:
+		name = boostedName
+	case ModePartial:
+		name = partialName
+	}
+
+	h.beforeRender(mode)
+	return renderer.Render(h, name, data)
+}
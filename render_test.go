@@ -0,0 +1,387 @@
+package htmx
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func renderFull(w io.Writer) error {
+	_, err := io.WriteString(w, "full")
+	return err
+}
+
+func renderPartial(w io.Writer) error {
+	_, err := io.WriteString(w, "partial")
+	return err
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{name: "full page", expected: "full"},
+		{name: "htmx", headers: map[string]string{"HX-Request": "true"}, expected: "partial"},
+		{name: "boosted", headers: map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, expected: "partial"},
+		{name: "history restore", headers: map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, expected: "full"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			handler := New().NewHandler(rec, r)
+			handler.ReTarget(reTarget)
+
+			if err := handler.Render(renderFull, renderPartial); err != nil {
+				t.Fatal(err)
+			}
+
+			equal(t, tt.expected, rec.Body.String())
+			equal(t, reTarget, rec.Header().Get(HXRetarget.String()))
+			if tt.expected == "partial" {
+				equal(t, "true", rec.Header().Get(DefaultFragmentHeader))
+			} else {
+				equal(t, "", rec.Header().Get(DefaultFragmentHeader))
+			}
+		})
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	expected := errors.New("template failed")
+
+	handler := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	err := handler.Render(func(io.Writer) error { return expected }, renderPartial)
+
+	if !errors.Is(err, expected) {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestRenderOrAbort(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	handler.Trigger("saved")
+
+	expected := errors.New("template failed")
+	err := handler.RenderOrAbort(func(w io.Writer) error {
+		_, _ = io.WriteString(w, "<p>half")
+		return expected
+	})
+
+	equalBool(t, true, errors.Is(err, expected))
+	equal(t, "", handler.ResponseHeader(HXTrigger))
+	equal(t, "", rec.Body.String())
+
+	handler.Error(http.StatusInternalServerError, "failed", err)
+	equal(t, `{"showMessage":{"level":"error","message":"failed"}}`, rec.Header().Get(HXTrigger.String()))
+	equal(t, "failed", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler = New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	handler.Trigger("saved")
+
+	if err := handler.RenderOrAbort(renderPartial); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "saved", rec.Header().Get(HXTrigger.String()))
+	equalBool(t, true, rec.Body.Len() > 0)
+}
+
+func TestValidationErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/signup", nil)
+	r.Header.Set(HxRequestHeaderRequest.String(), "true")
+	r.Header.Set(HxRequestHeaderTrigger.String(), "signup-form")
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, r)
+
+	var received map[string]string
+	err := handler.ValidationErrors(map[string]string{"email": "is required"}, func(w io.Writer, errs map[string]string) error {
+		received = errs
+		_, err := io.WriteString(w, `<form id="signup-form"><p>email `+errs["email"]+`</p></form>`)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equalInt(t, http.StatusUnprocessableEntity, rec.Code)
+	equal(t, "#signup-form", rec.Header().Get(HXRetarget.String()))
+	equal(t, "outerHTML", rec.Header().Get(HXReswap.String()))
+	equal(t, "is required", received["email"])
+	equal(t, `<form id="signup-form"><p>email is required</p></form>`, rec.Body.String())
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("page").Parse(`{{define "full"}}<html><body>{{template "partial" .}}</body></html>{{end}}{{define "partial"}}<p>{{.}}</p>{{end}}`))
+
+	rec := httptest.NewRecorder()
+	err := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil)).RenderTemplate(tmpl, "full", "partial", "<hi>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "<html><body><p>&lt;hi&gt;</p></body></html>", rec.Body.String())
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HxRequestHeaderRequest.String(), "true")
+	rec = httptest.NewRecorder()
+	err = New().NewHandler(rec, r).RenderTemplate(tmpl, "full", "partial", "<hi>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "<p>&lt;hi&gt;</p>", rec.Body.String())
+
+	err = New().NewHandler(httptest.NewRecorder(), r).RenderTemplate(tmpl, "full", "missing", nil)
+	if err == nil {
+		t.Error("expected an error for an undefined template")
+	}
+}
+
+func TestRenderTemplateBoosted(t *testing.T) {
+	tmpl := template.Must(template.New("page").Parse(`{{define "full"}}<html>{{template "shell" .}}</html>{{end}}` +
+		`{{define "shell"}}<nav></nav>{{template "partial" .}}{{end}}{{define "partial"}}<p>{{.}}</p>{{end}}`))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+	}{
+		{"full", nil, "<html><nav></nav><p>hi</p></html>"},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, "<nav></nav><p>hi</p>"},
+		{"partial", map[string]string{"HX-Request": "true"}, "<p>hi</p>"},
+		{"history restore", map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, "<html><nav></nav><p>hi</p></html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			if err := New().NewHandler(rec, r).RenderTemplateBoosted(tmpl, "full", "shell", "partial", "hi"); err != nil {
+				t.Fatal(err)
+			}
+			equal(t, tt.body, rec.Body.String())
+		})
+	}
+}
+
+func TestServeFullOnRestore(t *testing.T) {
+	full := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "full")
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		served  bool
+	}{
+		{"restore", map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, true},
+		{"boosted restore", map[string]string{"HX-Request": "true", "HX-Boosted": "true", "HX-History-Restore-Request": "true"}, true},
+		{"partial", map[string]string{"HX-Request": "true"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			handler := New().NewHandler(rec, r)
+
+			equalBool(t, tt.served, handler.IsHistoryRestore())
+			equalBool(t, tt.served, handler.ServeFullOnRestore(full))
+			equalBool(t, tt.served, rec.Body.String() == "full")
+		})
+	}
+}
+
+// upperRenderer is a template engine rendering the upper cased name
+type upperRenderer struct{}
+
+func (upperRenderer) Render(w io.Writer, name string, data any) error {
+	_, err := io.WriteString(w, strings.ToUpper(name))
+	return err
+}
+
+func TestRenderNamed(t *testing.T) {
+	tmpl := template.Must(template.New("page").Parse(`{{define "full"}}<html>{{.}}</html>{{end}}{{define "partial"}}{{.}}{{end}}`))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HxRequestHeaderRequest.String(), "true")
+
+	rec := httptest.NewRecorder()
+	if err := New().NewHandler(rec, r).RenderNamed(NewTemplateRenderer(tmpl), "full", "partial", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "hi", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	if err := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil)).RenderNamed(upperRenderer{}, "full", "partial", nil); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "FULL", rec.Body.String())
+}
+
+func TestRenderNamedBoosted(t *testing.T) {
+	for headers, expected := range map[string]string{"": "FULL", "boosted": "SHELL", "partial": "PARTIAL"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if headers != "" {
+			r.Header.Set("HX-Request", "true")
+		}
+		if headers == "boosted" {
+			r.Header.Set("HX-Boosted", "true")
+		}
+
+		rec := httptest.NewRecorder()
+		if err := New().NewHandler(rec, r).RenderNamedBoosted(upperRenderer{}, "full", "shell", "partial", nil); err != nil {
+			t.Fatal(err)
+		}
+		equal(t, expected, rec.Body.String())
+	}
+}
+
+func TestMarkFragment(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+
+	rec := httptest.NewRecorder()
+	if err := New(WithFragmentHeader("X-Partial")).NewHandler(rec, r).RenderNamed(upperRenderer{}, "full", "partial", nil); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "true", rec.Header().Get("X-Partial"))
+	equal(t, "", rec.Header().Get(DefaultFragmentHeader))
+
+	rec = httptest.NewRecorder()
+	if err := New(WithFragmentHeader("")).NewHandler(rec, r).Render(renderFull, renderPartial); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "", rec.Header().Get(DefaultFragmentHeader))
+
+	rec = httptest.NewRecorder()
+	if err := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil)).RenderNamed(upperRenderer{}, "full", "partial", nil); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "", rec.Header().Get(DefaultFragmentHeader))
+}
+
+func TestPartialNoStore(t *testing.T) {
+	for headers, expected := range map[string]string{"": "", "boosted": "no-store", "partial": "no-store"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if headers != "" {
+			r.Header.Set("HX-Request", "true")
+		}
+		if headers == "boosted" {
+			r.Header.Set("HX-Boosted", "true")
+		}
+
+		rec := httptest.NewRecorder()
+		if err := New(WithPartialNoStore()).NewHandler(rec, r).RenderNamedBoosted(upperRenderer{}, "full", "shell", "partial", nil); err != nil {
+			t.Fatal(err)
+		}
+		equal(t, expected, rec.Header().Get("Cache-Control"))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("HX-Request", "true")
+
+	rec := httptest.NewRecorder()
+	if err := New().NewHandler(rec, r).Render(renderFull, renderPartial); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "", rec.Header().Get("Cache-Control"))
+}
+
+func TestRenderModeHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		mode    string
+	}{
+		{"browser", nil, "full"},
+		{"partial", map[string]string{"HX-Request": "true"}, "partial"},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, "boosted"},
+		{"history restore", map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, "full"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			if err := New(WithRenderModeHeader()).NewHandler(rec, r).Render(renderFull, renderPartial); err != nil {
+				t.Fatal(err)
+			}
+			equal(t, tt.mode, rec.Header().Get(RenderModeHeader))
+
+			rec = httptest.NewRecorder()
+			if err := New(WithRenderModeHeader()).NewHandler(rec, r).RenderNamedBoosted(upperRenderer{}, "full", "shell", "partial", nil); err != nil {
+				t.Fatal(err)
+			}
+			equal(t, tt.mode, rec.Header().Get(RenderModeHeader))
+
+			rec = httptest.NewRecorder()
+			if err := New().NewHandler(rec, r).Render(renderFull, renderPartial); err != nil {
+				t.Fatal(err)
+			}
+			equal(t, "", rec.Header().Get(RenderModeHeader))
+		})
+	}
+}
+
+func TestFragmentOrPage(t *testing.T) {
+	fragment := func(w io.Writer) error {
+		_, err := io.WriteString(w, "<li>Pikachu</li>")
+		return err
+	}
+	page := func(w io.Writer) error {
+		_, err := io.WriteString(w, "<html><ul><li>Pikachu</li></ul></html>")
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		headers  map[string]string
+		expected string
+	}{
+		{"direct visit", nil, nil, "<html><ul><li>Pikachu</li></ul></html>"},
+		{"htmx", nil, map[string]string{"HX-Request": "true"}, "<li>Pikachu</li>"},
+		{"boosted", nil, map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, "<html><ul><li>Pikachu</li></ul></html>"},
+		{"boosted fragments", []Option{WithBoostedFragments()}, map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, "<li>Pikachu</li>"},
+		{"history restore", nil, map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, "<html><ul><li>Pikachu</li></ul></html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/pokemon/25", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			if err := New(tt.opts...).NewHandler(rec, r).FragmentOrPage(fragment, page); err != nil {
+				t.Fatal(err)
+			}
+			equal(t, tt.expected, rec.Body.String())
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package htmx
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -18,9 +21,40 @@ const (
 	HxRequestHeaderTrigger               HxRequestHeaderKey = "HX-Trigger"
 )
 
+// hxRequestHeaderKeys lists the htmx request headers, see StripHxHeaders.
+var hxRequestHeaderKeys = []HxRequestHeaderKey{
+	HxRequestHeaderBoosted,
+	HxRequestHeaderCurrentURL,
+	HxRequestHeaderHistoryRestoreRequest,
+	HxRequestHeaderPrompt,
+	HxRequestHeaderRequest,
+	HxRequestHeaderTarget,
+	HxRequestHeaderTriggerName,
+	HxRequestHeaderTrigger,
+}
+
+// ErrPromptMissing is returned by Handler.RequirePrompt when the request carries no HX-Prompt header.
+var ErrPromptMissing = errors.New("htmx: the request has no HX-Prompt header")
+
+// ErrNoEventDetail is returned by Handler.TriggerDetail when the request carries no event detail header.
+var ErrNoEventDetail = errors.New("htmx: the request has no event detail header")
+
+// ErrTriggerNotJSON is returned by Handler.TriggerJSON when the HX-Trigger request header is not a JSON object.
+var ErrTriggerNotJSON = errors.New("htmx: the HX-Trigger header is not a JSON object")
+
 type (
 	HxRequestHeaderKey string
 
+	// ClientInfo holds the version and extensions the client claims to have, see Handler.ClientInfo.
+	ClientInfo struct {
+		// Version is the version as sent, e.g. "2.0.3", empty when unknown.
+		Version             string
+		Major, Minor, Patch int
+		// Extensions are the names of the htmx extensions the client loaded.
+		Extensions []string
+	}
+
+	// HxRequestHeader holds the parsed htmx request headers, see HxHeader.
 	HxRequestHeader struct {
 		HxBoosted               bool
 		HxCurrentURL            string
@@ -33,19 +67,32 @@ type (
 	}
 )
 
+// HxRequestHeaderFromRequest parses the htmx request headers of r.
 func HxRequestHeaderFromRequest(r *http.Request) HxRequestHeader {
+	return hxRequestHeader(r, "")
+}
+
+// hxRequestHeader parses the htmx request headers of r, their names starting with prefix, see
+// WithRequestHeaderPrefix.
+func hxRequestHeader(r *http.Request, prefix string) HxRequestHeader {
+	get := func(k HxRequestHeaderKey) string {
+		return r.Header.Get(prefix + k.String())
+	}
+
 	return HxRequestHeader{
-		HxBoosted:               HxStrToBool(r.Header.Get(HxRequestHeaderBoosted.String())),
-		HxCurrentURL:            r.Header.Get(HxRequestHeaderCurrentURL.String()),
-		HxHistoryRestoreRequest: HxStrToBool(r.Header.Get(HxRequestHeaderHistoryRestoreRequest.String())),
-		HxPrompt:                r.Header.Get(HxRequestHeaderPrompt.String()),
-		HxRequest:               HxStrToBool(r.Header.Get(HxRequestHeaderRequest.String())),
-		HxTarget:                r.Header.Get(HxRequestHeaderTarget.String()),
-		HxTriggerName:           r.Header.Get(HxRequestHeaderTriggerName.String()),
-		HxTrigger:               r.Header.Get(HxRequestHeaderTrigger.String()),
+		HxBoosted:               HxStrToBool(get(HxRequestHeaderBoosted)),
+		HxCurrentURL:            get(HxRequestHeaderCurrentURL),
+		HxHistoryRestoreRequest: HxStrToBool(get(HxRequestHeaderHistoryRestoreRequest)),
+		HxPrompt:                get(HxRequestHeaderPrompt),
+		HxRequest:               HxStrToBool(get(HxRequestHeaderRequest)),
+		HxTarget:                get(HxRequestHeaderTarget),
+		HxTriggerName:           get(HxRequestHeaderTriggerName),
+		HxTrigger:               get(HxRequestHeaderTrigger),
 	}
 }
 
+// HxHeader returns the parsed htmx request headers, the value stored by a middleware in the request context is
+// reused when present. Handler.Request returns the same value without parsing again.
 func (h *HTMX) HxHeader(r *http.Request) HxRequestHeader {
 	header := r.Context().Value(ContextRequestHeader)
 
@@ -54,9 +101,124 @@ func (h *HTMX) HxHeader(r *http.Request) HxRequestHeader {
 	}
 
 	// if the header is not found from the middleware, try and populate it from the request
-	return HxRequestHeaderFromRequest(r)
+	return hxRequestHeader(r, h.requestPrefix)
+}
+
+// IsHxRequest is the package level IsHxRequest reading the request header prefix of the instance, see
+// WithRequestHeaderPrefix.
+func (h *HTMX) IsHxRequest(r *http.Request) bool {
+	return h.HxHeader(r).HxRequest
+}
+
+// IsHxBoosted is the package level IsHxBoosted reading the request header prefix of the instance, see
+// WithRequestHeaderPrefix.
+func (h *HTMX) IsHxBoosted(r *http.Request) bool {
+	return h.HxHeader(r).HxBoosted
+}
+
+// StripHxHeaders removes the htmx request headers from r, e.g. before proxying the request to an upstream that
+// should not see them. Only r.Header is changed, the parsed headers a middleware stored in the context are kept.
+func StripHxHeaders(r *http.Request) {
+	for _, k := range hxRequestHeaderKeys {
+		r.Header.Del(k.String())
+	}
+}
+
+// CopyHxHeaders copies the htmx request headers of src to dst, replacing the values dst has for them.
+// Headers absent from src are left alone in dst.
+func CopyHxHeaders(dst, src http.Header) {
+	for _, k := range hxRequestHeaderKeys {
+		if v := src.Values(k.String()); len(v) > 0 {
+			dst[http.CanonicalHeaderKey(k.String())] = append([]string(nil), v...)
+		}
+	}
+}
+
+// ParseClientInfo parses a client info header value: a version, optionally prefixed with "v" or "htmx/",
+// followed by "; ext=" and a comma separated list of extensions, e.g. "2.0.3; ext=sse,ws".
+// Parts it does not understand are left zero.
+func ParseClientInfo(val string) ClientInfo {
+	var info ClientInfo
+
+	version, params, _ := strings.Cut(val, ";")
+	version = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(version), "htmx/"), "v")
+	if version == "" {
+		return info
+	}
+
+	info.Version = version
+	numbers := []*int{&info.Major, &info.Minor, &info.Patch}
+	for i, part := range strings.SplitN(version, ".", len(numbers)) {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		*numbers[i] = n
+	}
+
+	for _, param := range strings.Split(params, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || k != "ext" {
+			continue
+		}
+
+		for _, ext := range strings.Split(v, ",") {
+			if ext = strings.TrimSpace(ext); ext != "" {
+				info.Extensions = append(info.Extensions, ext)
+			}
+		}
+	}
+
+	return info
+}
+
+// AtLeast returns true when the version is major.minor or later, false for an unknown version.
+func (c ClientInfo) AtLeast(major, minor int) bool {
+	if c.Version == "" {
+		return false
+	}
+
+	return c.Major > major || (c.Major == major && c.Minor >= minor)
+}
+
+// HasExtension returns true when the client claims to have loaded the named extension.
+func (c ClientInfo) HasExtension(name string) bool {
+	for _, ext := range c.Extensions {
+		if ext == name {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (x HxRequestHeaderKey) String() string {
 	return string(x)
 }
+
+// String returns the set headers in a form suited for debugging, e.g. {HX-Request: true, HX-Target: main}.
+func (h HxRequestHeader) String() string {
+	fields := make([]string, 0, 8)
+
+	add := func(k HxRequestHeaderKey, val string) {
+		if val != "" {
+			fields = append(fields, k.String()+": "+val)
+		}
+	}
+	addBool := func(k HxRequestHeaderKey, val bool) {
+		if val {
+			add(k, strconv.FormatBool(val))
+		}
+	}
+
+	addBool(HxRequestHeaderRequest, h.HxRequest)
+	addBool(HxRequestHeaderBoosted, h.HxBoosted)
+	addBool(HxRequestHeaderHistoryRestoreRequest, h.HxHistoryRestoreRequest)
+	add(HxRequestHeaderCurrentURL, h.HxCurrentURL)
+	add(HxRequestHeaderPrompt, h.HxPrompt)
+	add(HxRequestHeaderTarget, h.HxTarget)
+	add(HxRequestHeaderTrigger, h.HxTrigger)
+	add(HxRequestHeaderTriggerName, h.HxTriggerName)
+
+	return "{" + strings.Join(fields, ", ") + "}"
+}
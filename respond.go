@@ -0,0 +1,89 @@
+package htmx
+
+import (
+	"encoding/json"
+	"html/template"
+)
+
+// Responder stages the htmx response headers and the status of a response, see Handler.Respond.
+type Responder struct {
+	h *Handler
+}
+
+// Respond returns a Responder reading like the htmx response contract, e.g.
+//
+//	h.Respond().Retarget("#list").Reswap(SwapOuterHTML).Trigger("saved").Push("/items").HTML(body)
+//
+// The intermediate methods only stage headers through the setters of the handler, in any order, the terminal
+// HTML or JSON commits the status and every staged header with the body in one shot.
+func (h *Handler) Respond() *Responder {
+	return &Responder{h: h}
+}
+
+// Retarget sets HX-Retarget, see Handler.ReTarget.
+func (r *Responder) Retarget(selector string) *Responder {
+	r.h.ReTarget(selector)
+	return r
+}
+
+// Reswap sets HX-Reswap, see Handler.ReSwapStyle.
+func (r *Responder) Reswap(style SwapStyle) *Responder {
+	r.h.ReSwapStyle(style)
+	return r
+}
+
+// Reselect sets HX-Reselect, see Handler.ReSelect.
+func (r *Responder) Reselect(selector string) *Responder {
+	r.h.ReSelect(selector)
+	return r
+}
+
+// Trigger triggers the events at the default timing of the htmx instance, see Handler.TriggerWithObject.
+func (r *Responder) Trigger(events ...string) *Responder {
+	t := NewTrigger()
+	for _, event := range events {
+		t.AddEvent(event)
+	}
+
+	r.h.TriggerWithObject(t)
+	return r
+}
+
+// TriggerDetail triggers an event with a JSON encodable detail, see Trigger.
+func (r *Responder) TriggerDetail(event string, detail any) *Responder {
+	r.h.TriggerWithObject(NewTrigger().AddEventDetail(event, detail))
+	return r
+}
+
+// Push sets HX-Push-Url, see Handler.PushURL.
+func (r *Responder) Push(url string) *Responder {
+	r.h.PushURL(url)
+	return r
+}
+
+// Status sets the status code the terminal method commits, 200 OK by default, see Handler.Status.
+func (r *Responder) Status(code int) *Responder {
+	r.h.Status(code)
+	return r
+}
+
+// HTML commits the status and the staged headers and writes body as text/html.
+func (r *Responder) HTML(body template.HTML) error {
+	r.h.w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := r.h.WriteHTML(body)
+	return err
+}
+
+// JSON commits the status and the staged headers and writes the JSON encoding of v as application/json.
+// Unlike Handler.JSON the htmx headers are kept, e.g. for a client side template extension rendering the JSON.
+// An encoding error is returned before anything is written.
+func (r *Responder) JSON(v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	r.h.w.Header().Set("Content-Type", "application/json")
+	_, err = r.h.Write(payload)
+	return err
+}
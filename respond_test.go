@@ -0,0 +1,47 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespond(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/items", nil))
+
+	err := handler.Respond().
+		Retarget("#list").
+		Reswap(SwapOuterHTML).
+		Trigger("saved").
+		Push("/items/1").
+		Status(http.StatusCreated).
+		HTML("<li>one</li>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equalInt(t, http.StatusCreated, rec.Code)
+	equal(t, "#list", rec.Header().Get(HXRetarget.String()))
+	equal(t, "outerHTML", rec.Header().Get(HXReswap.String()))
+	equal(t, "saved", rec.Header().Get(HXTrigger.String()))
+	equal(t, "/items/1", rec.Header().Get(HXPushUrl.String()))
+	equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	equal(t, "<li>one</li>", rec.Body.String())
+}
+
+func TestRespondJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	if err := handler.Respond().TriggerDetail("loaded", 2).Status(http.StatusAccepted).JSON([]int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	equalInt(t, http.StatusAccepted, rec.Code)
+	equal(t, `{"loaded":2}`, rec.Header().Get(HXTrigger.String()))
+	equal(t, "application/json", rec.Header().Get("Content-Type"))
+	equal(t, "[1,2]", rec.Body.String())
+
+	equalBool(t, true, handler.Respond().JSON(make(chan int)) != nil)
+}
@@ -1,7 +1,9 @@
 package htmx
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 )
 
 type (
@@ -26,6 +28,10 @@ var (
 	HXTriggerAfterSwap   HxResponseKey = "HX-Trigger-After-Swap"   // allows you to trigger client side events, see the documentation for more info
 )
 
+// ErrInvalidHeaderValue is returned when a response header value contains a line break,
+// sending it would allow header injection.
+var ErrInvalidHeaderValue = errors.New("htmx: header value contains a line break")
+
 func (h *HTMX) HxResponseHeader(headers http.Header) *HxResponseHeader {
 	return &HxResponseHeader{
 		headers: headers,
@@ -40,6 +46,32 @@ func (h *HxResponseHeader) Set(k HxResponseKey, val string) {
 	h.headers.Set(k.String(), val)
 }
 
+func (h *HxResponseHeader) Del(k HxResponseKey) {
+	h.headers.Del(k.String())
+}
+
 func (h *HxResponseHeader) Get(k HxResponseKey) string {
 	return h.headers.Get(k.String())
 }
+
+// sanitizeHeaderValue rejects values that would break out of the header line.
+func sanitizeHeaderValue(val string) (string, error) {
+	if strings.ContainsAny(val, "\r\n") {
+		return "", ErrInvalidHeaderValue
+	}
+
+	return val, nil
+}
+
+// addVary adds the value to the Vary header unless it is already listed.
+func addVary(header http.Header, value string) {
+	for _, v := range header.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), value) {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", value)
+}
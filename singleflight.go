@@ -0,0 +1,103 @@
+package htmx
+
+import (
+	"html/template"
+	"net/http"
+	"sync"
+)
+
+// DefaultBusyMessage is the message SingleFlight answers a duplicate of a running request with.
+var DefaultBusyMessage = "This is already being processed, please wait."
+
+type (
+	// SingleFlightOption configures SingleFlight.
+	SingleFlightOption func(*singleFlight)
+
+	singleFlight struct {
+		key  func(*http.Request) string
+		busy func(*Handler)
+
+		mu       sync.Mutex
+		inflight map[string]bool
+	}
+)
+
+// WithBusyResponse sets the function responding to a duplicate of a running request, see SingleFlight.
+// It gets a handler of the htmx instance for the duplicate and must write the response.
+func WithBusyResponse(busy func(*Handler)) SingleFlightOption {
+	return func(s *singleFlight) {
+		s.busy = busy
+	}
+}
+
+// SingleFlight answers requests arriving while a request with the same key, as returned by key, is still
+// running with a busy response instead of running next concurrently, e.g. for a mutating action clicked twice.
+// By default the busy response is 409 Conflict with DefaultBusyMessage retargeted to the error box, like Error,
+// and a warning notification, see WithBusyResponse. Unlike Idempotent nothing is replayed: once the request
+// finished the key is free again. Requests with an empty key are passed to next.
+func (h *HTMX) SingleFlight(key func(*http.Request) string, opts ...SingleFlightOption) func(http.Handler) http.Handler {
+	s := &singleFlight{
+		key:      key,
+		busy:     busyResponse,
+		inflight: make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := s.key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !s.acquire(k) {
+				h.log.Debug("htmx: request already in flight", "method", r.Method, "path", r.URL.Path)
+
+				handler, ok := FromContext(r.Context())
+				if !ok {
+					handler = h.newHandler(w, r)
+					defer handler.Release()
+				}
+				s.busy(handler)
+				return
+			}
+			defer s.release(k)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire marks key in flight, it returns false when it already is.
+func (s *singleFlight) acquire(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inflight[key] {
+		return false
+	}
+
+	s.inflight[key] = true
+	return true
+}
+
+func (s *singleFlight) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.inflight, key)
+}
+
+// busyResponse is the default busy response of SingleFlight.
+func busyResponse(h *Handler) {
+	h.ReTarget(h.errorTarget).ReSwapStyle(SwapInnerHTML)
+	h.TriggerWarning(DefaultBusyMessage)
+
+	h.w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	h.WriteHeader(http.StatusConflict)
+	h.JustWriteString(template.HTMLEscapeString(DefaultBusyMessage))
+}
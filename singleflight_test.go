@@ -0,0 +1,70 @@
+package htmx
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSingleFlight(t *testing.T) {
+	started, unblock := make(chan struct{}), make(chan struct{})
+	runs := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		close(started)
+		<-unblock
+		_, _ = w.Write([]byte("saved"))
+	})
+
+	key := func(r *http.Request) string { return r.URL.Path }
+	handler := New().SingleFlight(key)(next)
+
+	first := httptest.NewRecorder()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/save", nil))
+	}()
+	<-started
+
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, httptest.NewRequest(http.MethodPost, "/save", nil))
+
+	close(unblock)
+	wg.Wait()
+
+	equalInt(t, 1, runs)
+	equal(t, "saved", first.Body.String())
+	equalInt(t, http.StatusConflict, second.Code)
+	equal(t, "#error", second.Header().Get(HXRetarget.String()))
+	equal(t, template.HTMLEscapeString(DefaultBusyMessage), second.Body.String())
+}
+
+func TestSingleFlightBusyResponse(t *testing.T) {
+	started, unblock := make(chan struct{}), make(chan struct{})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-unblock
+	})
+
+	busy := func(h *Handler) { h.TriggerOnly("busy") }
+	handler := New().SingleFlight(func(r *http.Request) string { return "save" }, WithBusyResponse(busy))(next)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/save", nil))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/save", nil))
+	close(unblock)
+	<-done
+
+	equalInt(t, http.StatusOK, rec.Code)
+	equal(t, "busy", rec.Header().Get(HXTrigger.String()))
+}
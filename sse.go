@@ -0,0 +1,143 @@
+package htmx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrFlushNotSupported is returned when the response writer cannot flush, which streaming responses depend on.
+var ErrFlushNotSupported = errors.New("htmx: the response writer does not support flushing")
+
+// SSEWriter streams server sent events to the htmx sse extension.
+// https://htmx.org/extensions/sse/
+type SSEWriter struct {
+	h *Handler
+}
+
+// SSE prepares the response for a server sent event stream and commits it.
+// Flushing goes through Handler.FlushError, so writers wrapped by middleware work as long as they implement
+// http.Flusher or expose the writer they wrap with an Unwrap method, which requires Go 1.20 or later.
+// It returns ErrFlushNotSupported, before anything is written, when no writer in the chain can flush.
+func (h *Handler) SSE() (*SSEWriter, error) {
+	if !canFlush(h.w) {
+		return nil, fmt.Errorf("%w: %T", ErrFlushNotSupported, h.w)
+	}
+
+	header := h.w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+
+	h.WriteHeader(http.StatusOK)
+	if err := h.FlushError(); err != nil {
+		return nil, err
+	}
+
+	return &SSEWriter{h: h}, nil
+}
+
+// Done returns a channel that is closed when the client disconnects, streaming loops should stop then.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.h.r.Context().Done()
+}
+
+// SendEvent sends an event with the given name, multi line data is split over several data fields.
+// Like the event stream format, \r\n, \r and \n all end a line, so data cannot inject fields of its own.
+// An empty event name sends an unnamed message event.
+func (s *SSEWriter) SendEvent(event, data string) error {
+	event, err := sanitizeHeaderValue(event)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+
+	for _, line := range strings.Split(sseLineEnds.Replace(data), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return s.send(b.String())
+}
+
+// sseLineEnds normalizes the line ends of the event stream format to \n.
+var sseLineEnds = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// SendJSON sends an event with the JSON encoding of v as data.
+func (s *SSEWriter) SendJSON(event string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.SendEvent(event, string(payload))
+}
+
+// SendTrigger sends the events, without detail, in the trigger event of the htmx instance, see
+// WithSSETriggerEvent. The data is the JSON object of HX-Trigger, event names mapped to their detail, encoded
+// with the trigger encoder, see WithTriggerEncoder, which the client passes to htmx.trigger.
+func (s *SSEWriter) SendTrigger(events ...string) error {
+	t := NewTrigger()
+	for _, event := range events {
+		t.AddEvent(event)
+	}
+
+	return s.sendTrigger(t)
+}
+
+// SendTriggerDetail sends an event with a JSON encodable detail in the trigger event, see SendTrigger.
+func (s *SSEWriter) SendTriggerDetail(name string, detail any) error {
+	return s.sendTrigger(NewTrigger().AddEventDetail(name, detail))
+}
+
+// sendTrigger sends the Trigger set as a JSON object, also when it only holds events without detail.
+func (s *SSEWriter) sendTrigger(t *Trigger) error {
+	t.onlySimple = false
+
+	data, err := s.h.encodeEvents(t)
+	if err != nil {
+		return err
+	}
+
+	return s.SendEvent(s.h.sseTriggerEvent, data)
+}
+
+// Ping sends a comment line, which keeps idle connections from being closed by proxies.
+func (s *SSEWriter) Ping() error {
+	return s.send(": ping\n\n")
+}
+
+// send writes the frame and flushes it, unless the client has gone away.
+func (s *SSEWriter) send(frame string) error {
+	if err := s.h.r.Context().Err(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(s.h, frame); err != nil {
+		return err
+	}
+
+	return s.h.FlushError()
+}
+
+// canFlush returns true when w, or one of the writers it wraps, can flush, as http.ResponseController sees it.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case http.Flusher, interface{ FlushError() error }:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
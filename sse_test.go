@@ -0,0 +1,122 @@
+package htmx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSSE(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	sse, err := handler.SSE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = sse.SendEvent("message", "<p>one</p>\n<p>two</p>")
+	_ = sse.SendJSON("count", map[string]int{"n": 1})
+	_ = sse.SendEvent("", "plain")
+	_ = sse.Ping()
+
+	expected := "event: message\ndata: <p>one</p>\ndata: <p>two</p>\n\n" +
+		"event: count\ndata: {\"n\":1}\n\n" +
+		"data: plain\n\n" +
+		": ping\n\n"
+
+	equal(t, expected, rec.Body.String())
+	equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	equalBool(t, true, rec.Flushed)
+
+	if err := sse.SendEvent("bad\nevent", "x"); !errors.Is(err, ErrInvalidHeaderValue) {
+		t.Errorf("expected %v, got %v", ErrInvalidHeaderValue, err)
+	}
+}
+
+func TestSSELineEnds(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse, err := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/events", nil)).SSE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = sse.SendEvent("msg", "hello\revent: admin\rdata: pwned")
+	_ = sse.SendEvent("msg", "a\r\nb")
+
+	expected := "event: msg\ndata: hello\ndata: event: admin\ndata: data: pwned\n\n" +
+		"event: msg\ndata: a\ndata: b\n\n"
+
+	equal(t, expected, rec.Body.String())
+}
+
+func TestSSESendTrigger(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse, err := New(WithSSETriggerEvent("trigger")).NewHandler(rec, httptest.NewRequest(http.MethodGet, "/events", nil)).SSE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = sse.SendTrigger("saved")
+	_ = sse.SendTriggerDetail("showMessage", map[string]string{"level": "info"})
+
+	expected := "event: trigger\ndata: {\"saved\":\"\"}\n\n" +
+		"event: trigger\ndata: {\"showMessage\":{\"level\":\"info\"}}\n\n"
+
+	equal(t, expected, rec.Body.String())
+}
+
+func TestSSEClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx))
+
+	sse, err := handler.SSE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	<-sse.Done()
+
+	if err := sse.SendEvent("message", "late"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	equal(t, "", rec.Body.String())
+}
+
+func TestSSENoFlusher(t *testing.T) {
+	handler := New().NewHandler(dummyWriter{}, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if _, err := handler.SSE(); !errors.Is(err, ErrFlushNotSupported) {
+		t.Errorf("expected %v, got %v", ErrFlushNotSupported, err)
+	}
+}
+
+// wrappedWriter hides the flusher of the writer it wraps, like many middleware writers do
+type wrappedWriter struct {
+	http.ResponseWriter
+}
+
+func (w wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestSSEResponseController(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(wrappedWriter{rec}, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	sse, err := handler.SSE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sse.SendEvent("message", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	equalBool(t, true, rec.Flushed)
+	equal(t, "event: message\ndata: hello\n\n", rec.Body.String())
+}
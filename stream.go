@@ -0,0 +1,65 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrStreamClosed is returned when pushing to a FragmentStream after Close.
+var ErrStreamClosed = errors.New("htmx: the fragment stream is closed")
+
+// FragmentStream streams html fragments, typically out of band swaps, over a single chunked response.
+// Every fragment is flushed as soon as it is pushed so the page updates progressively.
+// Unlike SSEWriter the fragments are sent as plain html without event stream framing.
+type FragmentStream struct {
+	h      *Handler
+	closed bool
+}
+
+// Stream commits the response as a chunked html stream. Like SSE it needs a writer that can flush
+// and returns ErrFlushNotSupported, before anything is written, otherwise.
+func (h *Handler) Stream() (*FragmentStream, error) {
+	if !canFlush(h.w) {
+		return nil, fmt.Errorf("%w: %T", ErrFlushNotSupported, h.w)
+	}
+
+	header := h.w.Header()
+	header.Del("Content-Length")
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	if err := h.FlushError(); err != nil {
+		return nil, err
+	}
+
+	return &FragmentStream{h: h}, nil
+}
+
+// Push writes the fragment and flushes it, unless the client has gone away or the stream is closed.
+func (s *FragmentStream) Push(fragment string) error {
+	if s.closed {
+		return ErrStreamClosed
+	}
+
+	if err := s.h.r.Context().Err(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(s.h, fragment); err != nil {
+		return err
+	}
+
+	return s.h.FlushError()
+}
+
+// Close ends the stream and finishes a compressed body, see Handler.Close.
+func (s *FragmentStream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	return s.h.Close()
+}
@@ -0,0 +1,57 @@
+package htmx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/import", nil))
+
+	stream, err := handler.Stream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalBool(t, true, rec.Flushed)
+	equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	for _, fragment := range []string{`<div id="progress" hx-swap-oob="true">50%</div>`, `<div id="progress" hx-swap-oob="true">100%</div>`} {
+		rec.Flushed = false
+		if err := stream.Push(fragment); err != nil {
+			t.Fatal(err)
+		}
+		equalBool(t, true, rec.Flushed)
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	equalBool(t, true, errors.Is(stream.Push("late"), ErrStreamClosed))
+	equal(t, `<div id="progress" hx-swap-oob="true">50%</div><div id="progress" hx-swap-oob="true">100%</div>`, rec.Body.String())
+}
+
+func TestStreamClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/import", nil).WithContext(ctx))
+
+	stream, err := handler.Stream()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	equalBool(t, true, errors.Is(stream.Push("late"), context.Canceled))
+	equal(t, "", rec.Body.String())
+}
+
+func TestStreamNoFlusher(t *testing.T) {
+	handler := New().NewHandler(dummyWriter{}, httptest.NewRequest(http.MethodPost, "/import", nil))
+
+	_, err := handler.Stream()
+	equalBool(t, true, errors.Is(err, ErrFlushNotSupported))
+}
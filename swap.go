@@ -1,35 +1,55 @@
 package htmx
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// ErrInvalidSwapStyle is returned when a swap style is not one htmx knows, see SwapStyle.Valid.
+var ErrInvalidSwapStyle = errors.New("htmx: invalid swap style")
+
 type Swap struct {
-	style       SwapStyle
-	transition  *bool
-	timing      *SwapTiming
-	scrolling   *SwapScrolling
-	ignoreTitle *bool
-	focusScroll *bool
+	style        SwapStyle
+	transition   *bool
+	swapTiming   *SwapTiming
+	settleTiming *SwapTiming
+	scrolling    *SwapScrolling
+	ignoreTitle  *bool
+	focusScroll  *bool
+	// withoutDefaults keeps the default timings out when the swap is sent, see WithoutDefaults
+	withoutDefaults bool
 }
 
 type SwapTiming struct {
 	mode     SwapTimingMode
 	duration time.Duration
+	// useDefault is set when no duration was given, the default of the htmx instance is used instead
+	useDefault bool
 }
 
 func (s *SwapTiming) String() string {
-	var out string
+	return s.render(s.duration)
+}
 
-	out = string(s.mode)
+// render returns the modifier using the given duration when the timing has no explicit duration
+func (s *SwapTiming) render(fallback time.Duration) string {
+	duration := s.duration
+	if s.useDefault {
+		duration = fallback
+	}
 
-	if s.duration != 0 {
-		out += ":" + s.duration.String()
+	return string(s.mode) + ":" + formatSwapDuration(duration)
+}
+
+// formatSwapDuration formats a duration the way htmx parses intervals, whole seconds as "1s" and anything else in milliseconds
+func formatSwapDuration(d time.Duration) string {
+	if d > 0 && d%time.Second == 0 {
+		return fmt.Sprintf("%ds", d/time.Second)
 	}
 
-	return out
+	return fmt.Sprintf("%dms", d/time.Millisecond)
 }
 
 type SwapScrolling struct {
@@ -112,35 +132,34 @@ func (s *Swap) ShowBottom(target ...string) *Swap {
 	return s.Show(SwapDirectionBottom, target...)
 }
 
-// setTiming modifies the amount of time that htmx will wait after receiving a response to swap or settle the content
-func (s *Swap) setTiming(mode SwapTimingMode, swap ...time.Duration) *Swap {
-	var duration time.Duration
+// newTiming modifies the amount of time that htmx will wait after receiving a response to swap or settle the content
+func newTiming(mode SwapTimingMode, fallback time.Duration, swap ...time.Duration) *SwapTiming {
 	if len(swap) > 0 {
-		duration = swap[0]
-	} else {
-		switch mode {
-		case TimingSwap:
-			duration = DefaultSwapDuration
-		case TimingSettle:
-			duration = DefaultSettleDelay
+		return &SwapTiming{
+			mode:     mode,
+			duration: swap[0],
 		}
 	}
 
-	s.timing = &SwapTiming{
-		mode:     mode,
-		duration: duration,
+	return &SwapTiming{
+		mode:       mode,
+		duration:   fallback,
+		useDefault: true,
 	}
-	return s
 }
 
-// Swap modifies the amount of time that htmx will wait after receiving a response to swap the content
+// Swap modifies the amount of time that htmx will wait after receiving a response to swap the content.
+// Without a duration the swap duration of the htmx instance is used, DefaultSwapDuration outside a handler.
 func (s *Swap) Swap(swap ...time.Duration) *Swap {
-	return s.setTiming(TimingSwap, swap...)
+	s.swapTiming = newTiming(TimingSwap, DefaultSwapDuration, swap...)
+	return s
 }
 
-// Settle modifies the amount of time that htmx will wait after receiving a response to settle the content
+// Settle modifies the amount of time that htmx will wait after receiving a response to settle the content.
+// Without a duration the settle delay of the htmx instance is used, DefaultSettleDelay outside a handler.
 func (s *Swap) Settle(swap ...time.Duration) *Swap {
-	return s.setTiming(TimingSettle, swap...)
+	s.settleTiming = newTiming(TimingSettle, DefaultSettleDelay, swap...)
+	return s
 }
 
 // Transition enables or disables the transition
@@ -162,8 +181,20 @@ func (s *Swap) FocusScroll(focusScroll bool) *Swap {
 	return s
 }
 
+// WithoutDefaults keeps the default swap and settle timings out of the HX-Reswap header and the hx-swap attribute,
+// only timings set with Swap and Settle are sent.
+func (s *Swap) WithoutDefaults() *Swap {
+	s.withoutDefaults = true
+	return s
+}
+
 // String returns the string representation of the Swap
 func (s *Swap) String() string {
+	return s.render(DefaultSwapDuration, DefaultSettleDelay)
+}
+
+// render returns the string representation of the Swap, timings without an explicit duration use the given defaults
+func (s *Swap) render(swapDuration, settleDelay time.Duration) string {
 	var parts []string
 
 	parts = append(parts, string(s.style))
@@ -184,13 +215,35 @@ func (s *Swap) String() string {
 		parts = append(parts, fmt.Sprintf("focus-scroll:%s", HxBoolToStr(*s.focusScroll)))
 	}
 
-	if s.timing != nil {
-		parts = append(parts, s.timing.String())
+	if s.swapTiming != nil {
+		parts = append(parts, s.swapTiming.render(swapDuration))
+	}
+
+	if s.settleTiming != nil {
+		parts = append(parts, s.settleTiming.render(settleDelay))
 	}
 
 	return strings.Join(parts, " ")
 }
 
+// renderWithDefaults is render with the swap and settle timings the Swap does not set taken from the given defaults,
+// unless WithoutDefaults was called.
+func (s *Swap) renderWithDefaults(swapDuration, settleDelay time.Duration) string {
+	if s.withoutDefaults {
+		return s.render(swapDuration, settleDelay)
+	}
+
+	swap := *s
+	if swap.swapTiming == nil {
+		swap.swapTiming = newTiming(TimingSwap, swapDuration)
+	}
+	if swap.settleTiming == nil {
+		swap.settleTiming = newTiming(TimingSettle, settleDelay)
+	}
+
+	return swap.render(swapDuration, settleDelay)
+}
+
 const (
 	// SwapInnerHTML replaces the inner html of the target element
 	SwapInnerHTML SwapStyle = "innerHTML"
@@ -223,6 +276,27 @@ func (s SwapStyle) String() string {
 	return string(s)
 }
 
+// Valid returns true for the swap styles htmx knows.
+func (s SwapStyle) Valid() bool {
+	switch s {
+	case SwapInnerHTML, SwapOuterHTML, SwapBeforeBegin, SwapAfterBegin, SwapBeforeEnd, SwapAfterEnd, SwapDelete, SwapNone:
+		return true
+	}
+
+	return false
+}
+
+// ParseSwapStyle returns the swap style named s, e.g. from a query parameter. Styles are case sensitive like
+// in htmx, an unknown one, also with surrounding spaces, returns ErrInvalidSwapStyle.
+func ParseSwapStyle(s string) (SwapStyle, error) {
+	style := SwapStyle(s)
+	if !style.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSwapStyle, s)
+	}
+
+	return style, nil
+}
+
 const (
 	// ScrollingScroll You can also change the scrolling behavior of the target element by using the scroll and show modifiers, both of which take the values top and bottom
 	ScrollingScroll SwapScrollingMode = "scroll"
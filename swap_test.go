@@ -1,6 +1,9 @@
 package htmx
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -42,8 +45,8 @@ func TestTimingSwap(t *testing.T) {
 	duration := 100 * time.Millisecond
 	swap := NewSwap().Swap(duration)
 
-	if swap.timing == nil || swap.timing.duration != duration {
-		t.Errorf("expected timing swap to be %v, got %v", duration, swap.timing.duration)
+	if swap.swapTiming == nil || swap.swapTiming.duration != duration {
+		t.Errorf("expected timing swap to be %v, got %v", duration, swap.swapTiming.duration)
 	}
 }
 
@@ -52,8 +55,8 @@ func TestTimingSettle(t *testing.T) {
 	duration := 200 * time.Millisecond
 	swap := NewSwap().Settle(duration)
 
-	if swap.timing == nil || swap.timing.duration != duration {
-		t.Errorf("expected timing settle to be %v, got %v", duration, swap.timing.duration)
+	if swap.settleTiming == nil || swap.settleTiming.duration != duration {
+		t.Errorf("expected timing settle to be %v, got %v", duration, swap.settleTiming.duration)
 	}
 }
 
@@ -151,3 +154,113 @@ func TestScrollingShowBottom(t *testing.T) {
 		t.Errorf("expected scrolling mode to be ScrollingShow, direction to be SwapDirectionBottom, and target to be %v, got mode: %v, direction: %v, target: %v", target, swap.scrolling.mode, swap.scrolling.direction, swap.scrolling.target)
 	}
 }
+
+// TestSwapAndSettle tests combining the swap and settle timings
+func TestSwapAndSettle(t *testing.T) {
+	swap := NewSwap().Swap(100 * time.Millisecond).Settle(20 * time.Millisecond)
+
+	expected := "innerHTML swap:100ms settle:20ms"
+	if swap.String() != expected {
+		t.Errorf("expected string output to be %s, got %s", expected, swap.String())
+	}
+}
+
+// TestTimingDefaults tests that timings without a duration use the defaults of the htmx instance
+func TestTimingDefaults(t *testing.T) {
+	swap := NewSwap().Style(SwapOuterHTML).Transition(true).Swap().Settle()
+
+	expected := "outerHTML transition:true swap:0ms settle:20ms"
+	if swap.String() != expected {
+		t.Errorf("expected string output to be %s, got %s", expected, swap.String())
+	}
+
+	handler := New(WithSwapDuration(50*time.Millisecond), WithSettleDelay(2*time.Second)).NewHandler(dummyWriter{}, &http.Request{})
+	handler.ReSwapWithObject(swap)
+
+	expected = "outerHTML transition:true swap:50ms settle:2s"
+	if handler.ResponseHeader(HXReswap) != expected {
+		t.Errorf("expected reswap header to be %s, got %s", expected, handler.ResponseHeader(HXReswap))
+	}
+}
+
+func TestSwapStyleValid(t *testing.T) {
+	equalBool(t, true, SwapOuterHTML.Valid())
+	equalBool(t, true, SwapNone.Valid())
+	equalBool(t, false, SwapStyle("sideways").Valid())
+	equalBool(t, false, SwapStyle("").Valid())
+}
+
+func TestParseSwapStyle(t *testing.T) {
+	style, err := ParseSwapStyle("beforeend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, SwapBeforeEnd.String(), style.String())
+
+	for _, s := range []string{"innerhtml ", "innerhtml", ""} {
+		_, err = ParseSwapStyle(s)
+		equalBool(t, true, errors.Is(err, ErrInvalidSwapStyle))
+	}
+}
+
+func TestReSwapStyle(t *testing.T) {
+	handler := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReSwapStyle(SwapOuterHTML)
+	equal(t, "outerHTML", handler.response.Get(HXReswap))
+
+	handler.ReSwapStyle("sideways")
+	equal(t, "outerHTML", handler.response.Get(HXReswap))
+
+	equal(t, `hx-swap="afterend"`, Attrs().SwapStyle(SwapAfterEnd).String())
+}
+
+func TestReSwapTiming(t *testing.T) {
+	handler := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReSwapTiming(100*time.Millisecond, 50*time.Millisecond)
+	equal(t, "swap:100ms settle:50ms", handler.ResponseHeader(HXReswap))
+
+	handler.ReSwapTiming(-time.Second, 0)
+	equal(t, "swap:100ms settle:50ms", handler.ResponseHeader(HXReswap))
+}
+
+func TestSwapDefaultsInjected(t *testing.T) {
+	handler := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReSwapWithObject(NewSwap())
+	equal(t, "innerHTML swap:0ms settle:20ms", handler.ResponseHeader(HXReswap))
+
+	handler.ReSwapWithObject(NewSwap().Style(SwapOuterHTML).WithoutDefaults())
+	equal(t, "outerHTML", handler.ResponseHeader(HXReswap))
+
+	handler = New(WithSwapDuration(100*time.Millisecond), WithSettleDelay(time.Second)).
+		NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	handler.ReSwapWithObject(NewSwap().Swap(50 * time.Millisecond))
+	equal(t, "innerHTML swap:50ms settle:1s", handler.ResponseHeader(HXReswap))
+
+	equal(t, `hx-swap="innerHTML swap:0ms settle:20ms"`, Attrs().SwapWithObject(NewSwap()).String())
+	equal(t, `hx-swap="innerHTML swap:100ms settle:1s"`, New(WithSwapDuration(100*time.Millisecond), WithSettleDelay(time.Second)).Attrs().SwapWithObject(NewSwap()).String())
+}
+
+func TestReSwapPreset(t *testing.T) {
+	handler := New().NewHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if err := handler.ReSwapPreset("fade"); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "innerHTML transition:true swap:0ms settle:20ms", handler.ResponseHeader(HXReswap))
+
+	if err := RegisterSwapPreset("pop", SwapBeforeEnd, 100*time.Millisecond, time.Second, "scroll:bottom"); err != nil {
+		t.Fatal(err)
+	}
+	_ = handler.ReSwapPreset("pop")
+	equal(t, "beforeend scroll:bottom swap:100ms settle:1s", handler.ResponseHeader(HXReswap))
+
+	err := handler.ReSwapPreset("wobble")
+	equalBool(t, true, errors.Is(err, ErrUnknownSwapPreset))
+	equal(t, "beforeend scroll:bottom swap:100ms settle:1s", handler.ResponseHeader(HXReswap))
+
+	equalBool(t, true, errors.Is(RegisterSwapPreset("bad", "sideways", 0, 0), ErrInvalidSwapStyle))
+}
@@ -0,0 +1,76 @@
+package htmx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrRenderTimeout is returned by Handler.WithTimeout when the render function did not return in time.
+var ErrRenderTimeout = errors.New("htmx: render timed out")
+
+// DefaultTimeoutMessage is the message shown to the user when Handler.WithTimeout gives up on a render.
+var DefaultTimeoutMessage = "This is taking too long, please try again."
+
+// WithTimeout runs fn with a handler whose request context is canceled after d, so slow template execution or
+// data fetching cannot hang the request. fn writes into a buffer, see Buffered: when it returns in time its
+// htmx response headers, other headers, status and body are written to the response. When it returns an error
+// nothing is written and the error is returned for the caller to answer.
+// When d passes first, a 503 Service Unavailable error fragment with DefaultTimeoutMessage is committed through
+// Error, retargeted to the error box, and ErrRenderTimeout is returned. What fn had rendered so far is dropped,
+// it keeps running in the background until it returns, so it should stop on the canceled context. fn must not
+// use the streaming helpers or flush, the buffered handler does not reach the client.
+func (h *Handler) WithTimeout(d time.Duration, fn func(*Handler) error) error {
+	ctx, cancel := context.WithTimeout(h.r.Context(), d)
+	defer cancel()
+
+	child, buf := h.Buffered()
+	child.r = h.r.WithContext(ctx)
+
+	done := make(chan error, 1)
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		done <- fn(child)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case p := <-panicked:
+		panic(p)
+	case <-ctx.Done():
+		if err := h.r.Context().Err(); err != nil {
+			return fmt.Errorf("htmx: request done before rendering the response: %w", err)
+		}
+
+		h.Error(http.StatusServiceUnavailable, DefaultTimeoutMessage, ErrRenderTimeout)
+		return ErrRenderTimeout
+	}
+
+	h.MergeHeaders(child)
+	bw := child.w.(*bufferWriter)
+	status := bw.status
+	if status == 0 {
+		status = child.status
+	}
+
+	header := h.w.Header()
+	for k, v := range bw.header {
+		if _, staged := child.response.headers[k]; k != "Vary" && !staged {
+			header[k] = v
+		}
+	}
+
+	h.WriteHeader(status)
+	_, err := h.Write(buf.Bytes())
+	return err
+}
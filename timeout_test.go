@@ -0,0 +1,48 @@
+package htmx
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	err := handler.WithTimeout(10*time.Millisecond, func(h *Handler) error {
+		select {
+		case <-time.After(time.Second):
+		case <-h.Context().Done():
+		}
+		_, err := h.WriteString("late")
+		return err
+	})
+
+	equalBool(t, true, errors.Is(err, ErrRenderTimeout))
+	equalInt(t, http.StatusServiceUnavailable, rec.Code)
+	equal(t, "#error", rec.Header().Get(HXRetarget.String()))
+	equal(t, template.HTMLEscapeString(DefaultTimeoutMessage), rec.Body.String())
+}
+
+func TestWithTimeoutInTime(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	err := handler.WithTimeout(time.Second, func(h *Handler) error {
+		h.Trigger("saved")
+		h.Header().Set("Content-Type", "text/html; charset=utf-8")
+		h.WriteHeader(http.StatusCreated)
+		_, err := h.WriteString("<p>done</p>")
+		return err
+	})
+
+	equalBool(t, true, err == nil)
+	equalInt(t, http.StatusCreated, rec.Code)
+	equal(t, "saved", rec.Header().Get(HXTrigger.String()))
+	equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	equal(t, "<p>done</p>", rec.Body.String())
+}
@@ -1,8 +1,12 @@
 package htmx
 
 import (
+	"bytes"
 	"encoding/json"
+	"html/template"
+	"reflect"
 	"strings"
+	"time"
 )
 
 type eventContent struct {
@@ -13,6 +17,8 @@ type eventContent struct {
 type Trigger struct {
 	triggers   []eventContent
 	onlySimple bool
+	// conflicts lists the events whose details were replaced
+	conflicts []string
 }
 
 // NewTrigger returns a new Trigger set
@@ -23,13 +29,28 @@ func NewTrigger() *Trigger {
 	}
 }
 
-// add adds a trigger to the Trigger set
+// add adds a trigger to the Trigger set, an event already in the set is replaced so every event is sent once.
+// Replacing the details of an event is recorded as a conflict, see merge.
 func (t *Trigger) add(trigger eventContent) *Trigger {
+	for i, existing := range t.triggers {
+		if existing.event != trigger.event {
+			continue
+		}
+
+		if !reflect.DeepEqual(existing.data, trigger.data) {
+			t.conflicts = append(t.conflicts, trigger.event)
+		}
+		t.triggers[i] = trigger
+
+		return t
+	}
+
 	t.triggers = append(t.triggers, trigger)
 
 	return t
 }
 
+// AddEvent adds a trigger without details to the Trigger set
 func (t *Trigger) AddEvent(event string) *Trigger {
 	return t.add(eventContent{event: event, data: ""})
 }
@@ -48,8 +69,38 @@ func (t *Trigger) AddEventObject(event string, details map[string]any) *Trigger
 	return t.add(eventContent{event: event, data: details})
 }
 
-// String returns the string representation of the Trigger set
+// AddEventDetail adds a trigger with any JSON encodable detail to the Trigger set
+func (t *Trigger) AddEventDetail(event string, detail any) *Trigger {
+	t.onlySimple = false
+
+	return t.add(eventContent{event: event, data: detail})
+}
+
+// merge adds the events of other to the Trigger set, an event already in the set takes the details of other.
+// It returns the names of the events whose details were replaced, in other or by the merge.
+func (t *Trigger) merge(other *Trigger) (conflicts []string) {
+	t.onlySimple = t.onlySimple && other.onlySimple
+	t.conflicts = append(t.conflicts[:0], other.conflicts...)
+
+	for _, tr := range other.triggers {
+		t.add(tr)
+	}
+
+	conflicts, t.conflicts = t.conflicts, nil
+	return conflicts
+}
+
+// String returns the string representation of the Trigger set.
+// Events without details are joined with a comma in the order they were added, as soon as one event carries
+// details all events are JSON encoded, with the event names sorted, see WithOrderedTriggers to keep the order.
 func (t *Trigger) String() string {
+	data, _ := t.encode(json.Marshal)
+	return data
+}
+
+// encode returns the string representation of the Trigger set, encoding the events with enc when one of them
+// carries details. enc receives a map of event names to details.
+func (t *Trigger) encode(enc func(any) ([]byte, error)) (string, error) {
 	if t.onlySimple {
 		data := make([]string, len(t.triggers))
 
@@ -57,15 +108,52 @@ func (t *Trigger) String() string {
 			data[i] = trigger.event
 		}
 
-		return strings.Join(data, ", ")
+		return strings.Join(data, ", "), nil
 	}
 
 	triggerMap := make(map[string]any)
 	for _, tr := range t.triggers {
 		triggerMap[tr.event] = tr.data
 	}
-	data, _ := json.Marshal(triggerMap)
-	return string(data)
+	data, err := enc(triggerMap)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// encodeOrdered is encode keeping the events of the JSON object in the order they were added, enc then receives
+// the details one by one. The client dispatches the events in the order of the object keys.
+func (t *Trigger) encodeOrdered(enc func(any) ([]byte, error)) (string, error) {
+	if t.onlySimple {
+		return t.encode(enc)
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+
+	for i, tr := range t.triggers {
+		key, err := json.Marshal(tr.event)
+		if err != nil {
+			return "", err
+		}
+
+		detail, err := enc(tr.data)
+		if err != nil {
+			return "", err
+		}
+
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(detail)
+	}
+
+	b.WriteByte('}')
+	return b.String(), nil
 }
 
 const (
@@ -83,22 +171,56 @@ const (
 	notificationKeyMessage = "message"
 )
 
+// TriggerOverflow returns the value sent in the trigger header k instead of val, the encoded events, when val
+// exceeds the trigger size limit, see WithTriggerSizeLimit.
+type TriggerOverflow func(h *Handler, k HxResponseKey, val string) string
+
+// TriggerOverflowOOB returns a TriggerOverflow triggering only event, and delivering the events in an out of band
+// fragment holding a <script type="application/json"> with the encoded events. The fragment replaces the element
+// whose id is id followed by the lower case header, e.g. "events-hx-trigger" for the id "events", so every trigger
+// timing has its own. The client listens for event and passes the events it reads from the element to htmx.trigger.
+// The out of band fragment is only sent when the body is written through the OOB builder, see OOB.Write.
+func TriggerOverflowOOB(event, id string) TriggerOverflow {
+	return func(h *Handler, k HxResponseKey, val string) string {
+		script := `<script type="application/json">` + strings.ReplaceAll(val, "</", `<\/`) + `</script>`
+		h.OOB().set(id+"-"+strings.ToLower(k.String()), template.HTML(script))
+
+		return event
+	}
+}
+
 type notificationType string
 
 func (n *notificationType) String() string {
 	return string(*n)
 }
 
-func (h *Handler) notifyObject(nt notificationType, message string, vars ...map[string]any) {
-	details := map[string]any{
-		notificationKeyLevel:   nt,
+// defaultNotificationSchema is the notification detail used unless WithNotificationSchema is set.
+func defaultNotificationSchema(level, message string) any {
+	return map[string]any{
+		notificationKeyLevel:   level,
 		notificationKeyMessage: message,
 	}
+}
+
+func (h *Handler) notifyObject(nt notificationType, message string, vars ...map[string]any) {
+	h.TriggerWithObject(NewTrigger().AddEventDetail(h.notificationKey, h.notificationDetail(nt, message, vars...)))
+}
+
+// notificationDetail returns the detail of a notification, built by the notification schema of the htmx instance.
+func (h *Handler) notificationDetail(nt notificationType, message string, vars ...map[string]any) any {
+	detail := h.notificationSchema(nt.String(), message)
+
+	// vars are only merged into object details, their keys taken by the schema are prefixed with an underscore
+	if details, ok := detail.(map[string]any); ok && len(vars) > 0 {
+		reserved := make(map[string]bool, len(details))
+		for k := range details {
+			reserved[k] = true
+		}
 
-	if len(vars) > 0 {
 		for _, m := range vars {
 			for k, v := range m {
-				if k == notificationKeyLevel || k == notificationKeyMessage {
+				if reserved[k] {
 					k = "_" + k
 				}
 				details[k] = v
@@ -106,9 +228,91 @@ func (h *Handler) notifyObject(nt notificationType, message string, vars ...map[
 		}
 	}
 
-	t := NewTrigger().AddEventObject(DefaultNotificationKey, details)
+	return detail
+}
+
+// ToastOptions configures Handler.Toast.
+type ToastOptions struct {
+	// Level is the notification level, "info" when empty.
+	Level string
+	// Duration is how long the toast shows before it is dismissed, sent in milliseconds. Zero sends no duration,
+	// the client keeps the toast until the user closes it.
+	Duration time.Duration
+}
+
+// Toast triggers the notification event, like Notify, with HX-Trigger-After-Swap so the toast shows once the
+// swap is done. Its detail, built by the notification schema, see WithNotificationSchema, carries the toast
+// duration in milliseconds under "duration", prefixed with an underscore when the schema uses that key.
+func (h *Handler) Toast(message string, opts ToastOptions) {
+	level := notificationType(opts.Level)
+	if level == "" {
+		level = notificationInfo
+	}
+
+	var vars []map[string]any
+	if opts.Duration > 0 {
+		vars = append(vars, map[string]any{"duration": opts.Duration.Milliseconds()})
+	}
+
+	h.TriggerAfterSwapWithObject(NewTrigger().AddEventDetail(h.notificationKey, h.notificationDetail(level, message, vars...)))
+}
+
+// Notify triggers the notification event, named after the notification key of the htmx instance,
+// with a {"level": level, "message": message} detail. TriggerSuccess, TriggerInfo, TriggerWarning and TriggerError
+// are shortcuts for the built-in levels.
+func (h *Handler) Notify(level, message string, vars ...map[string]any) {
+	h.notifyObject(notificationType(level), message, vars...)
+}
+
+// WithLoading wraps a slow operation in a pair of events: start is sent with HX-Trigger, whatever the default
+// timing, stop with HX-Trigger-After-Settle once fn returned. When fn fails its error is logged, an error
+// notification with DefaultErrorMessage is triggered, the error text is not meant for the user, and the error is
+// returned.
+// Both events arrive with the same response, start cannot reach the client before fn is done. Show the spinner
+// with hx-indicator while the request is in flight and use start and stop to update the page around the swap.
+func (h *Handler) WithLoading(start, stop string, fn func() error) error {
+	h.TriggerNowWithObject(NewTrigger().AddEvent(start))
+
+	err := fn()
+
+	h.TriggerAfterSettleWithObject(NewTrigger().AddEvent(stop))
+	if err != nil {
+		h.log.Error("htmx: loading operation failed", "path", h.r.URL.Path, "error", err)
+		h.TriggerError(DefaultErrorMessage)
+	}
+
+	return err
+}
+
+// AdvanceStep triggers event with HX-Trigger, whatever the default timing, to move a multi step form to step.
+// The detail is the fields of detail, a map or a struct encoded as a JSON object, with "step" set to step, e.g.
+// {"step":2,"title":"Address"}, a "step" field of detail is replaced. A nil detail sends the step alone, one that
+// does not encode as a JSON object is logged and dropped. Combine it with ReTarget so the fragment of the step
+// lands in the container of the form.
+func (h *Handler) AdvanceStep(event string, step int, detail any) {
+	fields := map[string]any{}
+	if detail != nil {
+		if err := decodeObject(detail, &fields); err != nil {
+			h.log.Warn("htmx: dropping the step detail, it is not a JSON object", "event", event, "error", err)
+			fields = map[string]any{}
+		}
+	}
+	fields["step"] = step
+
+	h.TriggerNowWithObject(NewTrigger().AddEventDetail(event, fields))
+}
+
+// decodeObject decodes the JSON encoding of v into fields, keeping numbers as they were encoded.
+func decodeObject(v any, fields *map[string]any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
 
-	h.TriggerWithObject(t)
+	return dec.Decode(fields)
 }
 
 func (h *Handler) TriggerSuccess(message string, vars ...map[string]any) {
@@ -1,8 +1,14 @@
 package htmx
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewTriggerMixed(t *testing.T) {
@@ -61,6 +67,72 @@ func TestNewTriggerMixedNested(t *testing.T) {
 	}
 }
 
+func TestNewTriggerDetail(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	trigger := NewTrigger().
+		AddEvent("refresh").
+		AddEventDetail("itemAdded", item{ID: 1, Name: "book"}).
+		AddEventDetail("count", 3)
+
+	expected := `{"count":3,"itemAdded":{"id":1,"name":"book"},"refresh":""}`
+
+	if trigger.String() != expected {
+		t.Errorf("expected trigger to be %v, got %v", expected, trigger.String())
+	}
+}
+
+func TestTriggerMerge(t *testing.T) {
+	log := &recordLogger{}
+	rec := httptest.NewRecorder()
+	handler := New(WithLogger(log)).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.TriggerWithObject(NewTrigger().AddEvent("itemSaved"))
+	handler.TriggerWithObject(NewTrigger().AddEvent("refreshList"))
+	handler.TriggerAfterSwapWithObject(NewTrigger().AddEventDetail("count", 1))
+	handler.TriggerAfterSwapWithObject(NewTrigger().AddEventDetail("count", 2).AddEvent("done"))
+	handler.JustWriteString("")
+
+	equal(t, "itemSaved, refreshList", rec.Header().Get(HXTrigger.String()))
+	equal(t, `{"count":2,"done":""}`, rec.Header().Get(HXTriggerAfterSwap.String()))
+	equalInt(t, 1, len(log.entries))
+}
+
+func TestTriggerMergeNotifications(t *testing.T) {
+	handler := New().NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEvent("itemSaved"))
+	handler.TriggerSuccess("saved")
+
+	expected := `{"itemSaved":"","showMessage":{"level":"success","message":"saved"}}`
+
+	equal(t, expected, handler.response.Get(HXTrigger))
+}
+
+func TestWithLoading(t *testing.T) {
+	handler := New().NewHandler(dummyWriter{}, &http.Request{})
+
+	err := handler.WithLoading("loadStart", "loadStop", func() error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "loadStart", handler.response.Get(HXTrigger))
+	equal(t, "loadStop", handler.response.Get(HXTriggerAfterSettle))
+
+	log := &recordLogger{}
+	handler = New(WithLogger(log)).NewHandler(dummyWriter{}, &http.Request{URL: &url.URL{Path: "/import"}})
+	expected := errors.New("pq: relation \"imports\" does not exist")
+
+	err = handler.WithLoading("loadStart", "loadStop", func() error { return expected })
+	equalBool(t, true, errors.Is(err, expected))
+	equal(t, `{"loadStart":"","showMessage":{"level":"error","message":"`+DefaultErrorMessage+`"}}`,
+		handler.response.Get(HXTrigger))
+	equal(t, "error: htmx: loading operation failed", strings.Join(log.entries, "\n"))
+	equal(t, "loadStop", handler.response.Get(HXTriggerAfterSettle))
+}
+
 func TestTriggerSuccess(t *testing.T) {
 	req := &http.Request{}
 	handler := New().NewHandler(dummyWriter{}, req)
@@ -100,3 +172,191 @@ func TestTriggerWarning(t *testing.T) {
 
 	equal(t, expected, handler.response.Get(HXTrigger))
 }
+
+func TestTriggerNotificationKeyOption(t *testing.T) {
+	req := &http.Request{}
+	handler := New(WithNotificationKey("toast")).NewHandler(dummyWriter{}, req)
+	handler.TriggerInfo("custom key")
+
+	expected := `{"toast":{"level":"info","message":"custom key"}}`
+
+	equal(t, expected, handler.response.Get(HXTrigger))
+}
+
+func TestNotify(t *testing.T) {
+	req := &http.Request{}
+	handler := New(WithNotificationKey("toast")).NewHandler(dummyWriter{}, req)
+	handler.Notify("debug", "tested a notification")
+
+	expected := `{"toast":{"level":"debug","message":"tested a notification"}}`
+
+	equal(t, expected, handler.response.Get(HXTrigger))
+}
+
+func TestNotificationSchema(t *testing.T) {
+	schema := func(level, message string) any {
+		return map[string]any{"type": level, "text": message}
+	}
+
+	handler := New(WithNotificationSchema(schema)).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerError("x")
+
+	equal(t, `{"showMessage":{"text":"x","type":"error"}}`, handler.response.Get(HXTrigger))
+
+	handler = New(WithNotificationSchema(schema)).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerInfo("x", map[string]any{"type": "other", "id": 3})
+
+	equal(t, `{"showMessage":{"_type":"other","id":3,"text":"x","type":"info"}}`, handler.response.Get(HXTrigger))
+
+	handler = New(WithNotificationSchema(func(level, message string) any { return level + ": " + message })).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWarning("x", map[string]any{"id": 3})
+
+	equal(t, `{"showMessage":"warning: x"}`, handler.response.Get(HXTrigger))
+}
+
+func TestTriggerTimings(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New().NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.TriggerWithObject(NewTrigger().AddEvent("saved"))
+	handler.TriggerAfterSwapWithObject(NewTrigger().AddEventDetail("focus", "#name"))
+	handler.TriggerAfterSettleWithObject(NewTrigger().AddEvent("analytics").AddEvent("done"))
+	handler.JustWriteString("")
+
+	equal(t, "saved", rec.Header().Get(HXTrigger.String()))
+	equal(t, `{"focus":"#name"}`, rec.Header().Get(HXTriggerAfterSwap.String()))
+	equal(t, "analytics, done", rec.Header().Get(HXTriggerAfterSettle.String()))
+}
+
+func TestTriggerDeduplication(t *testing.T) {
+	trigger := NewTrigger().AddEvent("refreshList").AddEvent("refreshList").AddEvent("refreshList")
+	equal(t, "refreshList", trigger.String())
+
+	trigger = NewTrigger().AddEventDetail("count", 1).AddEvent("refreshList").AddEventDetail("count", 1)
+	equal(t, `{"count":1,"refreshList":""}`, trigger.String())
+
+	log := &recordLogger{}
+	handler := New(WithLogger(log)).NewHandler(dummyWriter{}, &http.Request{})
+
+	handler.TriggerWithObject(NewTrigger().AddEventDetail("count", 1).AddEventDetail("count", 2))
+	handler.TriggerWithObject(NewTrigger().AddEvent("refreshList"))
+	handler.TriggerWithObject(NewTrigger().AddEvent("refreshList"))
+
+	equal(t, `{"count":2,"refreshList":""}`, handler.response.Get(HXTrigger))
+	equalInt(t, 1, len(log.entries))
+}
+
+func TestDefaultTriggerTiming(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler := New(WithDefaultTriggerTiming(HXTriggerAfterSettle)).NewHandler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	handler.TriggerWithObject(NewTrigger().AddEvent("saved"))
+	handler.TriggerSuccess("done")
+	handler.TriggerNowWithObject(NewTrigger().AddEvent("now"))
+	handler.TriggerAfterSwapWithObject(NewTrigger().AddEvent("swapped"))
+	handler.JustWriteString("")
+
+	equal(t, `{"saved":"","showMessage":{"level":"success","message":"done"}}`, rec.Header().Get(HXTriggerAfterSettle.String()))
+	equal(t, "now", rec.Header().Get(HXTrigger.String()))
+	equal(t, "swapped", rec.Header().Get(HXTriggerAfterSwap.String()))
+
+	handler = New(WithDefaultTriggerTiming(HXRetarget)).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEvent("saved"))
+	equal(t, "saved", handler.response.Get(HXTrigger))
+}
+
+func TestTriggerEncoderOption(t *testing.T) {
+	upper := func(v any) ([]byte, error) {
+		events := make(map[string]any)
+		for k, detail := range v.(map[string]any) {
+			events[strings.ToUpper(k)] = detail
+		}
+
+		return json.Marshal(events)
+	}
+
+	handler := New(WithTriggerEncoder(upper)).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEventDetailed("saved", "ok"))
+
+	equal(t, `{"SAVED":"ok"}`, handler.response.Get(HXTrigger))
+
+	handler.TriggerAfterSwapWithObject(NewTrigger().AddEvent("plain"))
+	equal(t, "plain", handler.response.Get(HXTriggerAfterSwap))
+
+	failing := func(any) ([]byte, error) { return nil, errors.New("boom") }
+	handler = New(WithTriggerEncoder(failing)).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEventDetailed("saved", "ok"))
+
+	equal(t, "", handler.response.Get(HXTrigger))
+}
+
+func TestTriggerSizeLimit(t *testing.T) {
+	log := &recordLogger{}
+	detail := strings.Repeat("x", 64)
+
+	handler := New(WithLogger(log), WithTriggerSizeLimit(32, nil)).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEventDetail("loaded", detail))
+
+	equal(t, `{"loaded":"`+detail+`"}`, handler.response.Get(HXTrigger))
+	equal(t, "warn: htmx: triggered events exceed the header size limit, proxies may drop or truncate the header", log.entries[0])
+
+	rec := httptest.NewRecorder()
+	overflow := TriggerOverflowOOB("eventsOverflow", "events")
+	handler = New(WithTriggerSizeLimit(32, overflow)).NewHandler(rec, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEventDetail("loaded", detail))
+	handler.TriggerWithObject(NewTrigger().AddEvent("saved"))
+	_, _ = handler.OOB().Write(handler, "<p>main</p>")
+
+	equal(t, "eventsOverflow", rec.Header().Get(HXTrigger.String()))
+	equal(t, `<p>main</p><div id="events-hx-trigger" hx-swap-oob="true"><script type="application/json">`+
+		`{"loaded":"`+detail+`","saved":""}</script></div>`, rec.Body.String())
+}
+
+func TestToast(t *testing.T) {
+	handler := New().NewHandler(dummyWriter{}, &http.Request{})
+
+	handler.Toast("Saved", ToastOptions{Level: "success", Duration: 3 * time.Second})
+	equal(t, `{"showMessage":{"duration":3000,"level":"success","message":"Saved"}}`, handler.response.Get(HXTriggerAfterSwap))
+	equal(t, "", handler.response.Get(HXTrigger))
+
+	handler = New().NewHandler(dummyWriter{}, &http.Request{})
+	handler.Toast("Hello", ToastOptions{})
+	equal(t, `{"showMessage":{"level":"info","message":"Hello"}}`, handler.response.Get(HXTriggerAfterSwap))
+}
+
+func TestAdvanceStep(t *testing.T) {
+	handler := New(WithDefaultTriggerTiming(HXTriggerAfterSwap)).NewHandler(dummyWriter{}, &http.Request{})
+
+	handler.ReTarget("#wizard")
+	handler.AdvanceStep("wizardStep", 2, struct {
+		Title string `json:"title"`
+		Total int    `json:"total"`
+		Step  int    `json:"step"`
+	}{Title: "Address", Total: 3, Step: 9})
+
+	equal(t, `{"wizardStep":{"step":2,"title":"Address","total":3}}`, handler.response.Get(HXTrigger))
+	equal(t, "#wizard", handler.response.Get(HXRetarget))
+
+	handler = New().NewHandler(dummyWriter{}, &http.Request{})
+	handler.AdvanceStep("wizardStep", 1, nil)
+	equal(t, `{"wizardStep":{"step":1}}`, handler.response.Get(HXTrigger))
+
+	handler = New().NewHandler(dummyWriter{}, &http.Request{})
+	handler.AdvanceStep("wizardStep", 3, "not an object")
+	equal(t, `{"wizardStep":{"step":3}}`, handler.response.Get(HXTrigger))
+}
+
+func TestTriggerInsertionOrder(t *testing.T) {
+	handler := New().NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEvent("zoomIn").AddEvent("appear").AddEvent("fadeOut"))
+	equal(t, "zoomIn, appear, fadeOut", handler.response.Get(HXTrigger))
+
+	handler = New(WithOrderedTriggers()).NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEvent("zoomIn").AddEventDetail("appear", map[string]any{"delay": 200}))
+	handler.TriggerWithObject(NewTrigger().AddEventDetailed("fadeOut", "slow"))
+	equal(t, `{"zoomIn":"","appear":{"delay":200},"fadeOut":"slow"}`, handler.response.Get(HXTrigger))
+
+	handler = New().NewHandler(dummyWriter{}, &http.Request{})
+	handler.TriggerWithObject(NewTrigger().AddEvent("zoomIn").AddEventDetail("appear", map[string]any{"delay": 200}).AddEventDetailed("fadeOut", "slow"))
+	equal(t, `{"appear":{"delay":200},"fadeOut":"slow","zoomIn":""}`, handler.response.Get(HXTrigger))
+}
@@ -0,0 +1,63 @@
+package htmx
+
+import (
+	"io"
+	"strings"
+)
+
+type (
+	// WSConn sends html fragments to the htmx ws extension over a websocket connection.
+	// The socket library stays pluggable, any io.Writer that writes a single message per call will do,
+	// use WSMessageWriter to adapt a gorilla style connection.
+	// Out of band fragments work over websockets too, they are swapped by id just like in a http response.
+	// https://htmx.org/extensions/ws/
+	WSConn struct {
+		w io.Writer
+	}
+
+	// MessageWriter is implemented by websocket connections that write whole messages,
+	// such as *websocket.Conn from gorilla/websocket.
+	MessageWriter interface {
+		WriteMessage(messageType int, data []byte) error
+	}
+
+	messageWriter struct {
+		conn MessageWriter
+	}
+)
+
+// wsTextMessage is the websocket opcode of a text message.
+const wsTextMessage = 1
+
+// NewWSConn returns a new WSConn writing every message to w.
+func NewWSConn(w io.Writer) *WSConn {
+	return &WSConn{
+		w: w,
+	}
+}
+
+// WSMessageWriter adapts a connection writing whole messages to an io.Writer sending text messages.
+func WSMessageWriter(conn MessageWriter) io.Writer {
+	return &messageWriter{
+		conn: conn,
+	}
+}
+
+// FormatWSMessage joins the fragments into a single message, the ws extension swaps every top level element by its id.
+func FormatWSMessage(fragments ...string) []byte {
+	return []byte(strings.Join(fragments, ""))
+}
+
+// SendHTML sends the fragments as a single message.
+func (c *WSConn) SendHTML(fragments ...string) error {
+	_, err := c.w.Write(FormatWSMessage(fragments...))
+	return err
+}
+
+func (m *messageWriter) Write(p []byte) (int, error) {
+	if err := m.conn.WriteMessage(wsTextMessage, p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
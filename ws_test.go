@@ -0,0 +1,40 @@
+package htmx
+
+import (
+	"bytes"
+	"testing"
+)
+
+type recordConn struct {
+	messageType int
+	messages    []string
+}
+
+func (c *recordConn) WriteMessage(messageType int, data []byte) error {
+	c.messageType = messageType
+	c.messages = append(c.messages, string(data))
+	return nil
+}
+
+func TestFormatWSMessage(t *testing.T) {
+	msg := FormatWSMessage(`<div id="chat" hx-swap-oob="beforeend"><p>hi</p></div>`, `<span id="count">2</span>`)
+
+	equal(t, `<div id="chat" hx-swap-oob="beforeend"><p>hi</p></div><span id="count">2</span>`, string(msg))
+}
+
+func TestWSConn(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWSConn(&buf).SendHTML("<p>a</p>", "<p>b</p>"); err != nil {
+		t.Fatal(err)
+	}
+	equal(t, "<p>a</p><p>b</p>", buf.String())
+
+	conn := &recordConn{}
+	ws := NewWSConn(WSMessageWriter(conn))
+	_ = ws.SendHTML("<p>a</p>")
+	_ = ws.SendHTML("<p>b</p>")
+
+	equalInt(t, wsTextMessage, conn.messageType)
+	equalInt(t, 2, len(conn.messages))
+	equal(t, "<p>b</p>", conn.messages[1])
+}
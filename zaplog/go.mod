@@ -0,0 +1,7 @@
+module github.com/developersismedika/go-htmx/zaplog
+
+go 1.20
+
+require go.uber.org/zap v1.26.0
+
+require go.uber.org/multierr v1.10.0 // indirect
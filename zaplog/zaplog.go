@@ -0,0 +1,57 @@
+// Package zaplog adapts a zap logger to the htmx.Logger interface.
+package zaplog
+
+import (
+	"go.uber.org/zap"
+)
+
+// newProduction builds the production logger, tests replace it to simulate a failing configuration
+var newProduction = zap.NewProduction
+
+// Logger wraps a zap.SugaredLogger so it satisfies htmx.Logger.
+type Logger struct {
+	log *zap.SugaredLogger
+}
+
+// New returns a new htmx logger backed by the given zap logger, a nil logger discards everything.
+func New(log *zap.Logger) *Logger {
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	return &Logger{
+		log: log.Sugar(),
+	}
+}
+
+// NewProduction returns a logger backed by zap's production configuration,
+// which is what htmx.New used before the logger became pluggable.
+// When the configuration cannot be built the logger discards everything instead of panicking on first use.
+func NewProduction() *Logger {
+	log, err := newProduction(zap.WithCaller(false))
+	if err != nil {
+		log = nil
+	}
+
+	return New(log)
+}
+
+// Debug logs a message at debug level with optional key/value pairs.
+func (l *Logger) Debug(msg string, keysAndValues ...any) {
+	l.log.Debugw(msg, keysAndValues...)
+}
+
+// Info logs a message at info level with optional key/value pairs.
+func (l *Logger) Info(msg string, keysAndValues ...any) {
+	l.log.Infow(msg, keysAndValues...)
+}
+
+// Warn logs a message at warn level with optional key/value pairs.
+func (l *Logger) Warn(msg string, keysAndValues ...any) {
+	l.log.Warnw(msg, keysAndValues...)
+}
+
+// Error logs a message at error level with optional key/value pairs.
+func (l *Logger) Error(msg string, keysAndValues ...any) {
+	l.log.Errorw(msg, keysAndValues...)
+}
@@ -0,0 +1,23 @@
+package zaplog
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestNewProductionFallback(t *testing.T) {
+	defer func(orig func(...zap.Option) (*zap.Logger, error)) { newProduction = orig }(newProduction)
+
+	newProduction = func(...zap.Option) (*zap.Logger, error) {
+		return nil, errors.New("no stderr")
+	}
+
+	log := NewProduction()
+	log.Error("must not panic", "key", "value")
+}
+
+func TestNewNil(t *testing.T) {
+	New(nil).Warn("must not panic")
+}